@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hotspot trend diffing.
+//
+// --hotspot-baseline loads a previous hotspots SARIF (or JSON) report and
+// compares it with the current ranking. Each current hotspot is classified as
+// new, rising, cooling or steady, and baseline files that no longer rank are
+// resolved and emitted as SARIF results with baselineState "absent". Results
+// carry SARIF baselineState plus the deltas so reviewers can focus on
+// hotspots that got worse in the change under review.
+
+const (
+	trendNew     = "new"
+	trendRising  = "rising"
+	trendCooling = "cooling"
+	trendSteady  = "steady"
+
+	// trendScoreTolerance ignores score jitter (e.g. decay weights shifting
+	// with the clock) when deciding whether a hotspot moved.
+	trendScoreTolerance = 0.01
+	// maxTrendSummaryFiles bounds the worsened files listed in logs and SARIF.
+	maxTrendSummaryFiles = 10
+)
+
+var messageScorePattern = regexp.MustCompile(`score=(-?[0-9.]+)`)
+
+type baselineEntry struct {
+	Score float64
+	Rank  int
+}
+
+// hotspotTrend describes how a hotspot moved relative to the baseline.
+type hotspotTrend struct {
+	State         string  `json:"state"`
+	BaselineScore float64 `json:"baseline_score"`
+	BaselineRank  int     `json:"baseline_rank"`
+	ScoreDelta    float64 `json:"score_delta"`
+	RankDelta     int     `json:"rank_delta"`
+}
+
+// sarifBaselineState maps a trend onto the SARIF 2.1 baselineState values.
+func (t hotspotTrend) sarifBaselineState() string {
+	switch t.State {
+	case trendNew:
+		return "new"
+	case trendRising, trendCooling:
+		return "updated"
+	default:
+		return "unchanged"
+	}
+}
+
+type trendSummary struct {
+	Baseline string   `json:"baseline"`
+	New      int      `json:"new"`
+	Rising   int      `json:"rising"`
+	Cooling  int      `json:"cooling"`
+	Steady   int      `json:"steady"`
+	Resolved int      `json:"resolved"`
+	Worsened []string `json:"worsened_changed,omitempty"`
+	// BaselineTotal sums every baseline score and CurrentTotal the current
+	// scores of those same files, so the rise check compares like with like
+	// even when the baseline was cut by --hotspot-limit, thresholds or scope.
+	BaselineTotal float64 `json:"baseline_score_total"`
+	CurrentTotal  float64 `json:"current_score_total"`
+	// resolved lists the baseline files that no longer rank, by baseline rank.
+	resolved []resolvedHotspot
+}
+
+// resolvedHotspot is a baseline hotspot missing from the current ranking.
+type resolvedHotspot struct {
+	File          string
+	BaselineScore float64
+	BaselineRank  int
+}
+
+// loadHotspotBaseline reads a hotspots SARIF log or a JSON report of the form
+// {"hotspots":[{"file":..,"score":..,"rank":..}]} (a bare array also works).
+func loadHotspotBaseline(path string) (map[string]baselineEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err == nil {
+		if _, ok := probe["runs"]; ok {
+			return parseSARIFBaseline(data)
+		}
+	}
+	return parseJSONBaseline(data)
+}
+
+func parseSARIFBaseline(data []byte) (map[string]baselineEntry, error) {
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("parse SARIF baseline: %w", err)
+	}
+	entries := map[string]baselineEntry{}
+	for _, run := range log.Runs {
+		rank := 0
+		for _, r := range run.Results {
+			// Absent results are resolved hotspots from an earlier diff, not
+			// part of that run's ranking.
+			if r.RuleID != hotspotRuleID || len(r.Locations) == 0 || r.BaselineState == "absent" {
+				continue
+			}
+			rank++
+			uri := r.Locations[0].PhysicalLocation.ArtifactLocation.URI
+			if uri == "" {
+				continue
+			}
+			entry := baselineEntry{Rank: rank}
+			if v, ok := r.Properties["score"].(float64); ok {
+				entry.Score = v
+			} else if m := messageScorePattern.FindStringSubmatch(r.Message.Text); m != nil {
+				entry.Score, _ = strconv.ParseFloat(strings.TrimSuffix(m[1], "."), 64)
+			}
+			if v, ok := r.Properties["rank"].(float64); ok && v > 0 {
+				entry.Rank = int(v)
+			}
+			if _, seen := entries[uri]; !seen {
+				entries[uri] = entry
+			}
+		}
+	}
+	return entries, nil
+}
+
+func parseJSONBaseline(data []byte) (map[string]baselineEntry, error) {
+	type row struct {
+		File  string  `json:"file"`
+		Score float64 `json:"score"`
+		Rank  int     `json:"rank"`
+	}
+	var doc struct {
+		Hotspots []row `json:"hotspots"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		var rows []row
+		if errArr := json.Unmarshal(data, &rows); errArr != nil {
+			return nil, fmt.Errorf("parse JSON baseline: %w", err)
+		}
+		doc.Hotspots = rows
+	}
+	entries := map[string]baselineEntry{}
+	for i, r := range doc.Hotspots {
+		if r.File == "" {
+			continue
+		}
+		rank := r.Rank
+		if rank == 0 {
+			rank = i + 1
+		}
+		entries[filepath.ToSlash(r.File)] = baselineEntry{Score: r.Score, Rank: rank}
+	}
+	return entries, nil
+}
+
+// applyHotspotBaseline sets Trend on every ranked hotspot and summarises the
+// movement. hs must hold the full ranking so dropped files are not mistaken
+// for resolved ones.
+func applyHotspotBaseline(hs []Hotspot, baseline map[string]baselineEntry, source string) *trendSummary {
+	summary := &trendSummary{Baseline: source}
+	current := make(map[string]bool, len(hs))
+	for i := range hs {
+		key := filepath.ToSlash(hs[i].File)
+		current[key] = true
+		prev, ok := baseline[key]
+		trend := &hotspotTrend{State: trendNew}
+		if ok {
+			summary.CurrentTotal += hs[i].Score
+			trend.BaselineScore = prev.Score
+			trend.BaselineRank = prev.Rank
+			trend.ScoreDelta = hs[i].Score - prev.Score
+			trend.RankDelta = prev.Rank - hs[i].Rank
+			switch {
+			case trend.ScoreDelta > trendScoreTolerance:
+				trend.State = trendRising
+			case trend.ScoreDelta < -trendScoreTolerance:
+				trend.State = trendCooling
+			default:
+				trend.State = trendSteady
+			}
+		}
+		hs[i].Trend = trend
+		switch trend.State {
+		case trendNew:
+			summary.New++
+		case trendRising:
+			summary.Rising++
+		case trendCooling:
+			summary.Cooling++
+		default:
+			summary.Steady++
+		}
+		if hs[i].Changed && (trend.State == trendNew || trend.State == trendRising) && len(summary.Worsened) < maxTrendSummaryFiles {
+			summary.Worsened = append(summary.Worsened, key)
+		}
+	}
+	for file, prev := range baseline {
+		summary.BaselineTotal += prev.Score
+		if !current[file] {
+			summary.Resolved++
+			summary.resolved = append(summary.resolved, resolvedHotspot{File: file, BaselineScore: prev.Score, BaselineRank: prev.Rank})
+		}
+	}
+	sort.Slice(summary.resolved, func(i, j int) bool {
+		a, b := summary.resolved[i], summary.resolved[j]
+		if a.BaselineRank != b.BaselineRank {
+			return a.BaselineRank < b.BaselineRank
+		}
+		return a.File < b.File
+	})
+	return summary
+}
+
+// sarifResult reports a resolved hotspot so code scanning can close it.
+func (r resolvedHotspot) sarifResult() SarifResult {
+	ruleIndex := 0
+	return SarifResult{
+		RuleID:    hotspotRuleID,
+		RuleIndex: &ruleIndex,
+		Level:     "note",
+		Message: SarifMessage{Text: fmt.Sprintf("Hotspot resolved: no longer ranked (baseline rank #%d, baseline score %.2f)",
+			r.BaselineRank, r.BaselineScore)},
+		Locations: []SarifLocation{{
+			PhysicalLocation: SarifPhysicalLocation{
+				ArtifactLocation: SarifArtifactLocation{URI: r.File},
+			},
+		}},
+		PartialFingerprints: map[string]string{hotspotFingerprintKey: hotspotFingerprint(r.File)},
+		BaselineState:       "absent",
+		Properties: map[string]any{
+			"trend":          "resolved",
+			"baseline_rank":  r.BaselineRank,
+			"baseline_score": r.BaselineScore,
+		},
+	}
+}
+
+func (s *trendSummary) logFields() LogFields {
+	return LogFields{
+		"baseline":         s.Baseline,
+		"new":              s.New,
+		"rising":           s.Rising,
+		"cooling":          s.Cooling,
+		"steady":           s.Steady,
+		"resolved":         s.Resolved,
+		"worsened_changed": s.Worsened,
+	}
+}
+
+// loadBaselineForConfig resolves --hotspot-baseline. A missing file is not an
+// error so the first CI run can seed the baseline.
+func loadBaselineForConfig(cfg *Config) (map[string]baselineEntry, bool, error) {
+	if cfg == nil || strings.TrimSpace(cfg.HotspotBaseline) == "" {
+		return nil, false, nil
+	}
+	entries, err := loadHotspotBaseline(cfg.HotspotBaseline)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			cfg.log().Warnf("hotspot baseline %s not found; skipping trend comparison", cfg.HotspotBaseline)
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("load hotspot baseline %s: %w", cfg.HotspotBaseline, err)
+	}
+	return entries, true, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadHotspotBaselineFormats(t *testing.T) {
+	dir := t.TempDir()
+	sarifPath := filepath.Join(dir, "old.sarif")
+	if err := writeSARIF(sarifPath, []Hotspot{{File: "a.go", Churn: 4, Score: 3.5}, {File: "b.go", Churn: 1, Score: 1.25}}); err != nil {
+		t.Fatalf("writeSARIF: %v", err)
+	}
+	got, err := loadHotspotBaseline(sarifPath)
+	if err != nil {
+		t.Fatalf("load SARIF baseline: %v", err)
+	}
+	if got["a.go"].Score != 3.5 || got["a.go"].Rank != 1 || got["b.go"].Rank != 2 {
+		t.Fatalf("unexpected SARIF baseline: %+v", got)
+	}
+
+	legacy := `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"PunchTrunk"}},"results":[` +
+		`{"ruleId":"hotspot","level":"note","message":{"text":"Hotspot candidate: churn=3, complexity=1.00, score=2.75"},` +
+		`"locations":[{"physicalLocation":{"artifactLocation":{"uri":"c.go"}}}]}]}]}`
+	legacyPath := filepath.Join(dir, "legacy.sarif")
+	if err := os.WriteFile(legacyPath, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("write legacy: %v", err)
+	}
+	if got, err := loadHotspotBaseline(legacyPath); err != nil || got["c.go"].Score != 2.75 {
+		t.Fatalf("expected score parsed from message, got %+v, %v", got, err)
+	}
+
+	jsonPath := filepath.Join(dir, "old.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"hotspots":[{"file":"d.go","score":1.5,"rank":7}]}`), 0o644); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	if got, err := loadHotspotBaseline(jsonPath); err != nil || got["d.go"].Rank != 7 {
+		t.Fatalf("expected JSON baseline, got %+v, %v", got, err)
+	}
+}
+
+func TestApplyHotspotBaseline(t *testing.T) {
+	baseline := map[string]baselineEntry{
+		"hot.go":    {Score: 2, Rank: 3},
+		"cool.go":   {Score: 5, Rank: 1},
+		"same.go":   {Score: 1, Rank: 4},
+		"fixed.go":  {Score: 4, Rank: 2},
+		"gone/x.go": {Score: 0.5, Rank: 5},
+	}
+	hs := []Hotspot{
+		{File: "hot.go", Score: 6, Rank: 1, Changed: true},
+		{File: "fresh.go", Score: 3, Rank: 2, Changed: true},
+		{File: "cool.go", Score: 2.5, Rank: 3},
+		{File: "same.go", Score: 1.005, Rank: 4},
+	}
+	summary := applyHotspotBaseline(hs, baseline, "old.sarif")
+	want := map[string]string{"hot.go": trendRising, "fresh.go": trendNew, "cool.go": trendCooling, "same.go": trendSteady}
+	for _, h := range hs {
+		if h.Trend == nil || h.Trend.State != want[h.File] {
+			t.Fatalf("%s: expected %s, got %+v", h.File, want[h.File], h.Trend)
+		}
+	}
+	if hs[0].Trend.RankDelta != 2 || hs[0].Trend.sarifBaselineState() != "updated" || hs[1].Trend.sarifBaselineState() != "new" {
+		t.Fatalf("unexpected deltas for hot.go: %+v", hs[0].Trend)
+	}
+	if summary.New != 1 || summary.Rising != 1 || summary.Cooling != 1 || summary.Steady != 1 || summary.Resolved != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if len(summary.Worsened) != 2 || summary.Worsened[0] != "hot.go" || summary.Worsened[1] != "fresh.go" {
+		t.Fatalf("expected changed new/rising files as worsened, got %v", summary.Worsened)
+
+	}
+	if len(summary.resolved) != 2 || summary.resolved[0].File != "fixed.go" || summary.resolved[1].File != "gone/x.go" {
+		t.Fatalf("expected resolved files in baseline rank order, got %+v", summary.resolved)
+	}
+}
+
+func TestHotspotSARIFEmitsAbsentResolvedResults(t *testing.T) {
+	baseline := map[string]baselineEntry{
+		"a.go":   {Score: 3, Rank: 1},
+		"old.go": {Score: 2, Rank: 2},
+	}
+	hs := []Hotspot{{File: "a.go", Score: 3, Rank: 1}}
+	report := &hotspotReport{Hotspots: hs, Trend: applyHotspotBaseline(hs, baseline, "old.sarif")}
+	path := filepath.Join(t.TempDir(), "out.sarif")
+	if err := writeHotspotSARIF(path, report); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("expected current and absent results, got %d", len(results))
+	}
+	absent := results[1]
+	if absent.BaselineState != "absent" || absent.Locations[0].PhysicalLocation.ArtifactLocation.URI != "old.go" {
+		t.Fatalf("unexpected absent result: %+v", absent)
+	}
+	if absent.PartialFingerprints[hotspotFingerprintKey] != hotspotFingerprint("old.go") {
+		t.Fatalf("expected absent result to keep the file fingerprint, got %v", absent.PartialFingerprints)
+	}
+	// Feeding this run back in as the next baseline must ignore absent results.
+	next, err := loadHotspotBaseline(path)
+	if err != nil {
+		t.Fatalf("loadHotspotBaseline: %v", err)
+	}
+	if _, ok := next["old.go"]; ok || len(next) != 1 {
+		t.Fatalf("expected absent results to be skipped, got %+v", next)
+	}
+}
+
+func TestAnalyzeHotspotsWithBaseline(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "a.go", "package a\n\nfunc A() {}\n")
+	writeFile(t, repo, "b.go", "package a\n\nfunc B() {}\n")
+	gitAddCommit(t, repo, "initial")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+	baselinePath := filepath.Join(repo, "baseline.json")
+	if err := os.WriteFile(baselinePath, []byte(`[{"file":"a.go","score":100}]`), 0o644); err != nil {
+		t.Fatalf("write baseline: %v", err)
+	}
+	cfg := &Config{BaseBranch: "HEAD", Timeout: 10 * time.Second, HotspotBaseline: baselinePath}
+	report, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	if report.Trend == nil || report.Trend.Cooling != 1 || report.Trend.New != 1 {
+		t.Fatalf("unexpected trend summary: %+v", report.Trend)
+	}
+	out := filepath.Join(repo, "out.sarif")
+	if err := writeHotspotSARIF(out, report); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	states := map[string]string{}
+	for _, r := range log.Runs[0].Results {
+		states[r.Locations[0].PhysicalLocation.ArtifactLocation.URI] = r.BaselineState
+	}
+	if states["a.go"] != "updated" || states["b.go"] != "new" {
+		t.Fatalf("unexpected baselineState values: %v", states)
+	}
+	if _, ok := log.Runs[0].Properties["trend"]; !ok {
+		t.Fatalf("expected trend summary in run properties, got %v", log.Runs[0].Properties)
+	}
+
+	cfg.HotspotBaseline = filepath.Join(repo, "missing.sarif")
+	if report, err := analyzeHotspots(context.Background(), cfg); err != nil || report.Trend != nil {
+		t.Fatalf("expected missing baseline to be skipped, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Incremental hotspot cache.
+//
+// Diffing every commit in the churn window dominates hotspot runs on large
+// repositories, yet a commit's numstat never changes. With --hotspot-cache
+// (the default) per-commit file stats are stored by SHA under
+// <trunk cache>/punchtrunk/hotspots/<repo>, or <tmp-dir>/punchtrunk/cache
+// when no Trunk cache directory is known. History is still enumerated to apply
+// the window, but only commits missing from the cache, normally those since
+// the last cached HEAD, are diffed. Complexity estimates are cached by git
+// blob hash, so unchanged files are not parsed again.
+
+const (
+	// hotspotCacheVersion invalidates cached numstat and complexity results
+	// when their format or the estimators change.
+	hotspotCacheVersion = 1
+
+	numstatCacheFile    = "numstat.json.gz"
+	complexityCacheFile = "complexity.json.gz"
+
+	// Past these sizes, entries not used by the current run are evicted.
+	maxCachedCommits    = 200000
+	maxCachedComplexity = 50000
+)
+
+type cachedCommit struct {
+	Time  int64         `json:"time"`
+	Files []numstatFile `json:"files,omitempty"`
+}
+
+type numstatCacheDoc struct {
+	Version int                     `json:"version"`
+	Head    string                  `json:"head,omitempty"`
+	Commits map[string]cachedCommit `json:"commits"`
+}
+
+type complexityCacheDoc struct {
+	Version int                       `json:"version"`
+	Entries map[string]fileComplexity `json:"entries"`
+}
+
+// hotspotCache holds both caches for one repository. A nil cache is valid and
+// caches nothing.
+type hotspotCache struct {
+	dir string
+
+	mu         sync.Mutex
+	numstat    numstatCacheDoc
+	complexity complexityCacheDoc
+	prevHead   string
+	usedSHA    map[string]bool
+	usedBlob   map[string]bool
+
+	commitHits, commitMisses         int
+	complexityHits, complexityMisses int
+	dirty                            bool
+}
+
+// hotspotCache opens the cache once per run; nil when disabled or unusable.
+func (cfg *Config) hotspotCache() *hotspotCache {
+	if cfg == nil || !cfg.HotspotCache {
+		return nil
+	}
+	cfg.cacheOnce.Do(func() {
+		dir, err := cfg.hotspotCacheDir()
+		if err == nil {
+			cfg.cache, err = openHotspotCache(dir)
+		}
+		if err != nil {
+			cfg.log().Warnf("hotspot cache disabled: %v", err)
+		}
+	})
+	return cfg.cache
+}
+
+// hotspotCacheDir picks the per-repository cache directory, keyed by the
+// absolute repository path so checkouts of different repositories never
+// share entries.
+func (cfg *Config) hotspotCacheDir() (string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("resolve repository path: %w", err)
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	sum := sha256.Sum256([]byte(root))
+	key := hex.EncodeToString(sum[:8])
+	if base := strings.TrimSpace(cfg.TrunkCacheDir); base != "" {
+		return filepath.Join(base, "punchtrunk", "hotspots", key), nil
+	}
+	return filepath.Join(cfg.tempDir(), "punchtrunk", "cache", key), nil
+}
+
+func openHotspotCache(dir string) (*hotspotCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure cache directory %s: %w", dir, err)
+	}
+	c := &hotspotCache{
+		dir:        dir,
+		numstat:    numstatCacheDoc{Version: hotspotCacheVersion, Commits: map[string]cachedCommit{}},
+		complexity: complexityCacheDoc{Version: hotspotCacheVersion, Entries: map[string]fileComplexity{}},
+		usedSHA:    map[string]bool{},
+		usedBlob:   map[string]bool{},
+	}
+	var numstat numstatCacheDoc
+	if err := readCacheFile(filepath.Join(dir, numstatCacheFile), &numstat); err == nil && numstat.Version == hotspotCacheVersion && numstat.Commits != nil {
+		c.numstat = numstat
+		c.prevHead = numstat.Head
+	}
+	var complexity complexityCacheDoc
+	if err := readCacheFile(filepath.Join(dir, complexityCacheFile), &complexity); err == nil && complexity.Version == hotspotCacheVersion && complexity.Entries != nil {
+		c.complexity = complexity
+	}
+	return c, nil
+}
+
+// commitFiles returns a copy of the cached files of sha; callers such as
+// foldRenames rewrite paths in place.
+func (c *hotspotCache) commitFiles(sha string, mode logMode) ([]numstatFile, bool) {
+	if c == nil || sha == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.numstat.Commits[sha]
+	if !ok {
+		c.commitMisses++
+		return nil, false
+	}
+	c.commitHits++
+	c.usedSHA[sha] = true
+	if len(entry.Files) == 0 {
+		return nil, true
+	}
+	files := make([]numstatFile, len(entry.Files))
+	for i, f := range entry.Files {
+		if mode == logNameOnly {
+			f = numstatFile{Path: f.Path}
+		}
+		files[i] = f
+	}
+	return files, true
+}
+
+// storeCommit records the unfolded numstat of a commit.
+func (c *hotspotCache) storeCommit(commit numstatCommit) {
+	if c == nil || commit.SHA == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.numstat.Commits[commit.SHA] = cachedCommit{
+		Time:  commit.Time.Unix(),
+		Files: append([]numstatFile(nil), commit.Files...),
+	}
+	c.usedSHA[commit.SHA] = true
+	c.dirty = true
+}
+
+// noteHead remembers the newest commit walked.
+func (c *hotspotCache) noteHead(commits []numstatCommit) {
+	if c == nil || len(commits) == 0 || commits[0].SHA == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.numstat.Head != commits[0].SHA {
+		c.numstat.Head = commits[0].SHA
+		c.dirty = true
+	}
+}
+
+// estimateComplexity is estimateComplexity keyed by the file's git blob hash
+// and estimator, so unchanged content is never re-estimated.
+func (c *hotspotCache) estimateComplexity(path, mode string) (fileComplexity, error) {
+	if c == nil {
+		return estimateComplexity(path, mode)
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fileComplexity{}, err
+	}
+	est := estimatorForPath(path, mode)
+	key := fmt.Sprintf("%T:%s", est, gitBlobHash(src))
+	c.mu.Lock()
+	fc, ok := c.complexity.Entries[key]
+	if ok {
+		c.complexityHits++
+		c.usedBlob[key] = true
+	}
+	c.mu.Unlock()
+	if ok {
+		return fc, nil
+	}
+	fc, err = est.Estimate(path, src)
+	if err != nil {
+		return fc, err
+	}
+	c.mu.Lock()
+	c.complexityMisses++
+	c.complexity.Entries[key] = fc
+	c.usedBlob[key] = true
+	c.dirty = true
+	c.mu.Unlock()
+	return fc, nil
+}
+
+// gitBlobHash matches `git hash-object`, so keys agree with the index.
+func gitBlobHash(src []byte) string {
+	h := sha1.New()
+	h.Write([]byte("blob " + strconv.Itoa(len(src)) + "\x00"))
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// flush writes the cache when it changed and logs how much was reused.
+func (c *hotspotCache) flush(cfg *Config) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cfg.log().Event("info", "hotspots.cache", LogFields{
+		"dir":                 c.dir,
+		"head":                c.numstat.Head,
+		"previous_head":       c.prevHead,
+		"commits_cached":      c.commitHits,
+		"commits_computed":    c.commitMisses,
+		"complexity_cached":   c.complexityHits,
+		"complexity_computed": c.complexityMisses,
+	})
+	if !c.dirty {
+		return
+	}
+	c.evict()
+	err := writeCacheFile(filepath.Join(c.dir, numstatCacheFile), c.numstat)
+	if err == nil {
+		err = writeCacheFile(filepath.Join(c.dir, complexityCacheFile), c.complexity)
+	}
+	if err != nil {
+		cfg.log().Warnf("unable to write hotspot cache: %v", err)
+		return
+	}
+	c.dirty = false
+}
+
+// evict drops entries unused by this run once a cache outgrows its cap,
+// oldest commits first.
+func (c *hotspotCache) evict() {
+	if excess := len(c.numstat.Commits) - maxCachedCommits; excess > 0 {
+		var unused []string
+		for sha := range c.numstat.Commits {
+			if !c.usedSHA[sha] {
+				unused = append(unused, sha)
+			}
+		}
+		sort.Slice(unused, func(i, j int) bool {
+			return c.numstat.Commits[unused[i]].Time < c.numstat.Commits[unused[j]].Time
+		})
+		for i := 0; i < excess && i < len(unused); i++ {
+			delete(c.numstat.Commits, unused[i])
+		}
+	}
+	if len(c.complexity.Entries) > maxCachedComplexity {
+		for key := range c.complexity.Entries {
+			if !c.usedBlob[key] {
+				delete(c.complexity.Entries, key)
+			}
+		}
+	}
+}
+
+func readCacheFile(path string, v any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return json.NewDecoder(zr).Decode(v)
+}
+
+// writeCacheFile replaces path atomically so concurrent runs never read a
+// partial cache.
+func writeCacheFile(path string, v any) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(v); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// cachedCLILog lists the window's commit headers with git log and computes
+// numstat only for commits the cache does not know, feeding their SHAs to
+// `git log --no-walk --stdin`.
+func cachedCLILog(ctx context.Context, cache *hotspotCache, window churnWindow, mode logMode) ([]numstatCommit, bool, error) {
+	commits, degraded, err := gitLogCommits(ctx, window, parseNumstatCommits)
+	if err != nil || len(commits) == 0 {
+		return commits, degraded, err
+	}
+	var missing []string
+	for i := range commits {
+		files, ok := cache.commitFiles(commits[i].SHA, mode)
+		if !ok {
+			missing = append(missing, commits[i].SHA)
+			continue
+		}
+		commits[i].Files = files
+	}
+	if len(missing) > 0 {
+		stdin := strings.Join(missing, "\n") + "\n"
+		output, stderr, err := runGitLogInput(ctx, stdin, "log", "--no-walk=unsorted", "--stdin", "--numstat", "--find-renames", numstatFormat)
+		if err != nil {
+			return nil, degraded, fmt.Errorf("git log --no-walk failed: %w: %s", err, strings.TrimSpace(stderr))
+		}
+		shallow, known := gitShallowCommits(ctx)
+		computed := map[string][]numstatFile{}
+		for _, c := range parseNumstatCommits(output) {
+			computed[c.SHA] = c.Files
+			// Boundary commits of a shallow clone diff against the empty
+			// tree; their stats change once the clone is deepened.
+			if known && !shallow[c.SHA] {
+				cache.storeCommit(c)
+			}
+		}
+		for i := range commits {
+			files, ok := computed[commits[i].SHA]
+			if !ok {
+				continue
+			}
+			if mode == logNameOnly {
+				for j := range files {
+					files[j] = numstatFile{Path: files[j].Path}
+				}
+			}
+			commits[i].Files = files
+		}
+	}
+	cache.noteHead(commits)
+	if mode == logNumstat {
+		commits = foldRenames(commits)
+	}
+	return commits, degraded, nil
+}
+
+// gitShallowCommits reads the shallow boundary of the current repository;
+// known is false when it could not be determined.
+func gitShallowCommits(ctx context.Context) (shallow map[string]bool, known bool) {
+	out, _, err := runGitLog(ctx, "rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return nil, false
+	}
+	shallow = map[string]bool{}
+	data, err := os.ReadFile(strings.TrimSpace(out))
+	if err != nil {
+		return shallow, errors.Is(err, fs.ErrNotExist)
+	}
+	for _, line := range strings.Fields(string(data)) {
+		shallow[line] = true
+	}
+	return shallow, true
+}
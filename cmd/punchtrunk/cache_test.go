@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func cachedConfig(cacheDir string) *Config {
+	cfg := &Config{HotspotCache: true, TrunkCacheDir: cacheDir}
+	cfg.logger = newEventLogger(io.Discard, false)
+	return cfg
+}
+
+func TestHotspotCacheReusesCommits(t *testing.T) {
+	historyFixture(t)
+	ctx := context.Background()
+	window := churnWindow{Since: defaultHotspotSince}
+	backends := map[string]func(*Config) (historySource, error){
+		"cli": func(cfg *Config) (historySource, error) { return &cliHistory{cfg: cfg}, nil },
+		"go-git": func(cfg *Config) (historySource, error) {
+			return openGoGitHistory(cfg)
+		},
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			run := func(mode logMode) ([]numstatCommit, *hotspotCache) {
+				t.Helper()
+				cfg := cachedConfig(cacheDir)
+				history, err := open(cfg)
+				if err != nil {
+					t.Fatalf("open history: %v", err)
+				}
+				commits, _, err := history.log(ctx, window, mode)
+				if err != nil {
+					t.Fatalf("log: %v", err)
+				}
+				cache := cfg.hotspotCache()
+				cache.flush(cfg)
+				return commits, cache
+			}
+			plain, err := open(&Config{logger: newEventLogger(io.Discard, false)})
+			if err != nil {
+				t.Fatalf("open history: %v", err)
+			}
+			for _, mode := range []logMode{logNumstat, logNameOnly} {
+				want, _, err := plain.log(ctx, window, mode)
+				if err != nil {
+					t.Fatalf("uncached log: %v", err)
+				}
+				_, _ = run(mode)
+				got, cache := run(mode)
+				if cache.commitMisses != 0 || cache.commitHits == 0 {
+					t.Fatalf("mode %d: expected a fully cached run, got %d hits and %d misses", mode, cache.commitHits, cache.commitMisses)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("mode %d: cached log differs:\n got %+v\nwant %+v", mode, got, want)
+				}
+			}
+
+			writeFile(t, ".", "NOTES.md", "notes\n"+name+"\n")
+			runGit(t, ".", "commit", "-qam", "notes from "+name)
+			commits, cache := run(logNumstat)
+			if cache.commitMisses != 1 {
+				t.Fatalf("expected only the new commit to be diffed, got %d misses", cache.commitMisses)
+			}
+			if cache.prevHead == "" || cache.numstat.Head != commits[0].SHA || cache.prevHead == commits[0].SHA {
+				t.Fatalf("expected head to advance from %q to %q, got %q", cache.prevHead, commits[0].SHA, cache.numstat.Head)
+			}
+		})
+	}
+}
+
+func TestHotspotCacheComplexityByBlob(t *testing.T) {
+	dir := t.TempDir()
+	oldCwd := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, oldCwd) })
+	cacheDir := t.TempDir()
+	src := "package demo\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"
+	writeFile(t, dir, "demo.go", src)
+
+	cfg := cachedConfig(cacheDir)
+	cache := cfg.hotspotCache()
+	want, err := estimateComplexity("demo.go", complexityModeAuto)
+	if err != nil {
+		t.Fatalf("estimateComplexity: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := cache.estimateComplexity("demo.go", complexityModeAuto)
+		if err != nil {
+			t.Fatalf("cached estimate: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("cached estimate %+v, want %+v", got, want)
+		}
+	}
+	if cache.complexityMisses != 1 || cache.complexityHits != 1 {
+		t.Fatalf("expected 1 miss then 1 hit, got %d misses, %d hits", cache.complexityMisses, cache.complexityHits)
+	}
+	cache.flush(cfg)
+
+	// Same content under another name shares the blob entry; new content and
+	// another estimator do not.
+	writeFile(t, dir, "copy.go", src)
+	writeFile(t, dir, "demo.go", src+"\nfunc g() {}\n")
+	cfg = cachedConfig(cacheDir)
+	cache = cfg.hotspotCache()
+	if _, err := cache.estimateComplexity("copy.go", complexityModeAuto); err != nil {
+		t.Fatalf("cached estimate: %v", err)
+	}
+	if _, err := cache.estimateComplexity("demo.go", complexityModeAuto); err != nil {
+		t.Fatalf("cached estimate: %v", err)
+	}
+	if _, err := cache.estimateComplexity("copy.go", complexityModeDensity); err != nil {
+		t.Fatalf("cached estimate: %v", err)
+	}
+	if cache.complexityHits != 1 || cache.complexityMisses != 2 {
+		t.Fatalf("expected 1 hit and 2 misses after reopening, got %d hits, %d misses", cache.complexityHits, cache.complexityMisses)
+	}
+}
+
+func TestHotspotCacheDir(t *testing.T) {
+	trunkCache := t.TempDir()
+	dir, err := (&Config{TrunkCacheDir: trunkCache}).hotspotCacheDir()
+	if err != nil {
+		t.Fatalf("hotspotCacheDir: %v", err)
+	}
+	if !strings.HasPrefix(dir, filepath.Join(trunkCache, "punchtrunk", "hotspots")) {
+		t.Fatalf("expected cache under the Trunk cache, got %s", dir)
+	}
+	tmp := t.TempDir()
+	dir, err = (&Config{TmpDir: tmp}).hotspotCacheDir()
+	if err != nil {
+		t.Fatalf("hotspotCacheDir: %v", err)
+	}
+	if !strings.HasPrefix(dir, filepath.Join(tmp, "punchtrunk", "cache")) {
+		t.Fatalf("expected cache under tmp-dir, got %s", dir)
+	}
+	if (&Config{}).hotspotCache() != nil {
+		t.Fatalf("expected no cache when --hotspot-cache is off")
+	}
+}
+
+func TestGitBlobHashMatchesGit(t *testing.T) {
+	// `printf 'hello\n' | git hash-object --stdin`
+	if got := gitBlobHash([]byte("hello\n")); got != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Fatalf("unexpected blob hash %s", got)
+	}
+}
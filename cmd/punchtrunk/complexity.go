@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Complexity estimation for hotspots.
+//
+// Estimators are chosen by file extension: Go sources are parsed with go/ast
+// and scored per function (cyclomatic + cognitive), other source files use an
+// indentation-aware branch keyword count, and data/markup files score zero so
+// long JSON or YAML lines no longer outrank nested logic.
+
+const (
+	complexityModeAuto    = "auto"
+	complexityModeDensity = "density"
+)
+
+type complexityEstimator interface {
+	Estimate(path string, src []byte) (fileComplexity, error)
+}
+
+// fileComplexity summarises a file. Total feeds Hotspot.Complexity and is the
+// sum of cyclomatic complexity across the file's decision points.
+type fileComplexity struct {
+	Total     float64              `json:"total"`
+	Cognitive float64              `json:"cognitive,omitempty"`
+	Functions []functionComplexity `json:"functions,omitempty"`
+}
+
+type functionComplexity struct {
+	Name       string `json:"name"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Cyclomatic int    `json:"cyclomatic"`
+	Cognitive  int    `json:"cognitive"`
+}
+
+var complexityEstimators = map[string]complexityEstimator{
+	".go": goComplexityEstimator{},
+}
+
+// dataExtensions never carry control flow worth scoring.
+var dataExtensions = map[string]struct{}{
+	".json": {}, ".yaml": {}, ".yml": {}, ".toml": {}, ".ini": {}, ".cfg": {},
+	".md": {}, ".markdown": {}, ".rst": {}, ".txt": {}, ".csv": {}, ".tsv": {},
+	".xml": {}, ".svg": {}, ".lock": {}, ".sum": {}, ".mod": {}, ".sarif": {},
+}
+
+func estimatorForPath(path, mode string) complexityEstimator {
+	if mode == complexityModeDensity {
+		return densityComplexityEstimator{}
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if est, ok := complexityEstimators[ext]; ok {
+		return est
+	}
+	if _, ok := dataExtensions[ext]; ok {
+		return dataComplexityEstimator{}
+	}
+	return genericComplexityEstimator{}
+}
+
+func validateComplexityMode(mode string) error {
+	switch mode {
+	case "", complexityModeAuto, complexityModeDensity:
+		return nil
+	}
+	return fmt.Errorf("unsupported hotspot-complexity %q (expected auto or density)", mode)
+}
+
+func estimateComplexity(path, mode string) (fileComplexity, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fileComplexity{}, err
+	}
+	return estimatorForPath(path, mode).Estimate(path, src)
+}
+
+type densityComplexityEstimator struct{}
+
+func (densityComplexityEstimator) Estimate(_ string, src []byte) (fileComplexity, error) {
+	return fileComplexity{Total: tokenDensity(src)}, nil
+}
+
+type dataComplexityEstimator struct{}
+
+func (dataComplexityEstimator) Estimate(string, []byte) (fileComplexity, error) {
+	return fileComplexity{}, nil
+}
+
+type goComplexityEstimator struct{}
+
+func (goComplexityEstimator) Estimate(path string, src []byte) (fileComplexity, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		// Unparseable sources (templates, mid-merge files) still deserve a score.
+		return genericComplexityEstimator{}.Estimate(path, src)
+	}
+	var out fileComplexity
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		fc := functionComplexity{
+			Name:       goFuncName(fn),
+			StartLine:  fset.Position(fn.Pos()).Line,
+			EndLine:    fset.Position(fn.End()).Line,
+			Cyclomatic: goCyclomatic(fn),
+			Cognitive:  goCognitive(fn),
+		}
+		out.Functions = append(out.Functions, fc)
+		out.Total += float64(fc.Cyclomatic)
+		out.Cognitive += float64(fc.Cognitive)
+	}
+	return out, nil
+}
+
+func goFuncName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if idx, ok := recv.(*ast.IndexExpr); ok {
+		recv = idx.X
+	}
+	if idx, ok := recv.(*ast.IndexListExpr); ok {
+		recv = idx.X
+	}
+	switch t := recv.(type) {
+	case *ast.StarExpr:
+		if id, ok := t.X.(*ast.Ident); ok {
+			return fmt.Sprintf("(*%s).%s", id.Name, fn.Name.Name)
+		}
+	case *ast.Ident:
+		return fmt.Sprintf("%s.%s", t.Name, fn.Name.Name)
+	}
+	return fn.Name.Name
+}
+
+// goCyclomatic counts McCabe decision points: 1 + if/for/range + non-default
+// case clauses + short-circuit operators. Closures count toward their parent.
+func goCyclomatic(fn *ast.FuncDecl) int {
+	complexity := 1
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// goCognitive follows the cognitive complexity model: structural breaks cost
+// 1 plus their nesting depth, else/else-if and labelled jumps cost 1, each run
+// of mixed boolean operators costs 1, and direct recursion costs 1.
+func goCognitive(fn *ast.FuncDecl) int {
+	v := &cognitiveVisitor{name: fn.Name.Name}
+	v.walk(fn.Body, 0)
+	return v.score
+}
+
+type cognitiveVisitor struct {
+	name  string
+	score int
+}
+
+func (v *cognitiveVisitor) walk(node ast.Node, nesting int) {
+	if node == nil {
+		return
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			v.score += 1 + nesting
+			v.ifStmt(n, nesting)
+			return false
+		case *ast.ForStmt:
+			v.score += 1 + nesting
+			v.walkStmt(n.Init, nesting)
+			v.walkExpr(n.Cond, nesting)
+			v.walkStmt(n.Post, nesting)
+			v.walk(n.Body, nesting+1)
+			return false
+		case *ast.RangeStmt:
+			v.score += 1 + nesting
+			v.walkExpr(n.X, nesting)
+			v.walk(n.Body, nesting+1)
+			return false
+		case *ast.SwitchStmt:
+			v.score += 1 + nesting
+			v.walkStmt(n.Init, nesting)
+			v.walkExpr(n.Tag, nesting)
+			v.walk(n.Body, nesting+1)
+			return false
+		case *ast.TypeSwitchStmt:
+			v.score += 1 + nesting
+			v.walkStmt(n.Init, nesting)
+			v.walkStmt(n.Assign, nesting)
+			v.walk(n.Body, nesting+1)
+			return false
+		case *ast.SelectStmt:
+			v.score += 1 + nesting
+			v.walk(n.Body, nesting+1)
+			return false
+		case *ast.FuncLit:
+			v.walk(n.Body, nesting+1)
+			return false
+		case *ast.BranchStmt:
+			if n.Label != nil {
+				v.score++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				v.logical(n, nesting)
+				return false
+			}
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok && id.Name == v.name {
+				v.score++
+			}
+		}
+		return true
+	})
+}
+
+func (v *cognitiveVisitor) walkStmt(s ast.Stmt, nesting int) {
+	if s != nil {
+		v.walk(s, nesting)
+	}
+}
+
+func (v *cognitiveVisitor) walkExpr(e ast.Expr, nesting int) {
+	if e != nil {
+		v.walk(e, nesting)
+	}
+}
+
+func (v *cognitiveVisitor) ifStmt(n *ast.IfStmt, nesting int) {
+	v.walkStmt(n.Init, nesting)
+	v.walkExpr(n.Cond, nesting)
+	v.walk(n.Body, nesting+1)
+	switch e := n.Else.(type) {
+	case *ast.IfStmt:
+		v.score++
+		v.ifStmt(e, nesting)
+	case *ast.BlockStmt:
+		v.score++
+		v.walk(e, nesting+1)
+	}
+}
+
+func (v *cognitiveVisitor) logical(expr *ast.BinaryExpr, nesting int) {
+	var ops []token.Token
+	var operands []ast.Expr
+	var flatten func(e ast.Expr)
+	flatten = func(e ast.Expr) {
+		if b, ok := e.(*ast.BinaryExpr); ok && (b.Op == token.LAND || b.Op == token.LOR) {
+			flatten(b.X)
+			ops = append(ops, b.Op)
+			flatten(b.Y)
+			return
+		}
+		operands = append(operands, e)
+	}
+	flatten(expr)
+	prev := token.ILLEGAL
+	for _, op := range ops {
+		if op != prev {
+			v.score++
+			prev = op
+		}
+	}
+	for _, operand := range operands {
+		v.walk(operand, nesting)
+	}
+}
+
+var (
+	branchKeywordPattern = regexp.MustCompile(`\b(if|elif|elsif|for|foreach|while|until|unless|case|when|catch|except|rescue)\b`)
+	logicalOpPattern     = regexp.MustCompile(`&&|\|\||\band\b|\bor\b`)
+)
+
+// genericComplexityEstimator approximates complexity for languages without a
+// dedicated parser: every branch keyword or short-circuit operator adds a
+// decision point, and cognitive weight grows with the line's indentation.
+type genericComplexityEstimator struct{}
+
+func (genericComplexityEstimator) Estimate(_ string, src []byte) (fileComplexity, error) {
+	lines := strings.Split(string(src), "\n")
+	unit := indentUnit(lines)
+	decisions := 0
+	cognitive := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || isCommentLine(trimmed) {
+			continue
+		}
+		count := len(branchKeywordPattern.FindAllString(trimmed, -1)) + len(logicalOpPattern.FindAllString(trimmed, -1))
+		if count == 0 {
+			continue
+		}
+		decisions += count
+		cognitive += count * (1 + indentDepth(line, unit))
+	}
+	if decisions == 0 && strings.TrimSpace(string(src)) == "" {
+		return fileComplexity{}, nil
+	}
+	return fileComplexity{Total: float64(1 + decisions), Cognitive: float64(cognitive)}, nil
+}
+
+func isCommentLine(trimmed string) bool {
+	for _, prefix := range []string{"//", "#", "/*", "*", "--", ";", "<!--"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// indentUnit picks the smallest space indentation in use, defaulting to 4.
+func indentUnit(lines []string) int {
+	unit := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		spaces := len(line) - len(strings.TrimLeft(line, " "))
+		if spaces > 0 && (unit == 0 || spaces < unit) {
+			unit = spaces
+		}
+	}
+	if unit == 0 {
+		return 4
+	}
+	return unit
+}
+
+func indentDepth(line string, unit int) int {
+	depth := 0
+	spaces := 0
+	for _, r := range line {
+		switch r {
+		case '\t':
+			depth++
+		case ' ':
+			spaces++
+		default:
+			return depth + spaces/unit
+		}
+	}
+	return depth + spaces/unit
+}
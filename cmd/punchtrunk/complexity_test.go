@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoComplexityEstimatorPerFunction(t *testing.T) {
+	src := `package demo
+
+func flat() int { return 1 }
+
+func nested(items []int, limit int) int {
+	total := 0
+	for _, v := range items { // +1 (nesting 0)
+		if v > limit && v%2 == 0 { // +2 (nesting 1), && +1
+			total += v
+		} else if v < 0 || v > 100 { // else-if +1, || +1
+			continue
+		} else { // else +1
+			total--
+		}
+	}
+	switch {
+	case total > 10:
+		return total
+	case total < 0:
+		return 0
+	}
+	return total
+}
+
+func (s *server) handle() {}
+`
+	fc, err := goComplexityEstimator{}.Estimate("demo.go", []byte(src))
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if len(fc.Functions) != 3 {
+		t.Fatalf("expected 3 functions, got %+v", fc.Functions)
+	}
+	byName := map[string]functionComplexity{}
+	for _, f := range fc.Functions {
+		byName[f.Name] = f
+	}
+	if got := byName["flat"]; got.Cyclomatic != 1 || got.Cognitive != 0 || got.StartLine != 3 {
+		t.Fatalf("unexpected flat metrics: %+v", got)
+	}
+	nested := byName["nested"]
+	// 1 + range + if + && + else-if + || + 2 cases
+	if nested.Cyclomatic != 8 {
+		t.Fatalf("expected cyclomatic 8 for nested, got %d", nested.Cyclomatic)
+	}
+	// range 1 + if 2 + && 1 + else-if 1 + || 1 + else 1 + switch 1
+	if nested.Cognitive != 8 {
+		t.Fatalf("expected cognitive 8 for nested, got %d", nested.Cognitive)
+	}
+	if nested.StartLine != 5 || nested.EndLine != 23 {
+		t.Fatalf("unexpected line range for nested: %d-%d", nested.StartLine, nested.EndLine)
+	}
+	if _, ok := byName["(*server).handle"]; !ok {
+		t.Fatalf("expected method name with receiver, got %+v", fc.Functions)
+	}
+	if fc.Total != 10 {
+		t.Fatalf("expected total cyclomatic 10, got %f", fc.Total)
+	}
+}
+
+func TestGoComplexityEstimatorFallsBackOnSyntaxError(t *testing.T) {
+	fc, err := goComplexityEstimator{}.Estimate("broken.go", []byte("package x\nfunc {\n\tif a && b {\n"))
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if fc.Total < 2 || len(fc.Functions) != 0 {
+		t.Fatalf("expected generic fallback score, got %+v", fc)
+	}
+}
+
+func TestComplexityRanksNestedCodeAboveData(t *testing.T) {
+	dir := t.TempDir()
+	minified := `{"a":[1,2,3],"b":{"c":"d","e":[{"f":1},{"g":2}]},"h":"` + strings.Repeat("x y ", 200) + `"}`
+	nestedPy := `def process(items):
+    for item in items:
+        if item.ready and item.valid:
+            while item.pending:
+                item.step()
+        elif item.failed:
+            raise ValueError(item)
+`
+	nestedGo := `package main
+
+func walk(nodes []node) {
+	for _, n := range nodes {
+		if n.ok {
+			for _, c := range n.children {
+				if c.ok {
+					walk(c.children)
+				}
+			}
+		}
+	}
+}
+`
+	files := map[string]string{"data.json": minified, "job.py": nestedPy, "walk.go": nestedGo}
+	scores := map[string]float64{}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		fc, err := estimateComplexity(path, complexityModeAuto)
+		if err != nil {
+			t.Fatalf("estimateComplexity %s: %v", name, err)
+		}
+		scores[name] = fc.Total
+	}
+	if scores["data.json"] != 0 {
+		t.Fatalf("expected data files to score 0, got %f", scores["data.json"])
+	}
+	if scores["walk.go"] <= scores["data.json"] || scores["job.py"] <= scores["data.json"] {
+		t.Fatalf("expected nested code to outrank JSON: %v", scores)
+	}
+	density, err := estimateComplexity(filepath.Join(dir, "data.json"), complexityModeDensity)
+	if err != nil {
+		t.Fatalf("density estimate: %v", err)
+	}
+	if density.Total <= scores["walk.go"] {
+		t.Fatalf("expected legacy density mode to keep ranking JSON high, got %f", density.Total)
+	}
+}
+
+func TestGenericComplexityEstimatorUsesIndentation(t *testing.T) {
+	shallow := "if a:\n  x()\nif b:\n  y()\n"
+	deep := "def f():\n  if a:\n    if b:\n      x()\n"
+	s, _ := genericComplexityEstimator{}.Estimate("a.py", []byte(shallow))
+	d, _ := genericComplexityEstimator{}.Estimate("b.py", []byte(deep))
+	if s.Total != d.Total {
+		t.Fatalf("expected equal decision counts, got %f and %f", s.Total, d.Total)
+	}
+	if d.Cognitive <= s.Cognitive {
+		t.Fatalf("expected nesting to raise cognitive weight: shallow=%f deep=%f", s.Cognitive, d.Cognitive)
+	}
+}
+
+func TestValidateComplexityMode(t *testing.T) {
+	for _, mode := range []string{"", "auto", "density"} {
+		if err := validateComplexityMode(mode); err != nil {
+			t.Fatalf("mode %q: unexpected error %v", mode, err)
+		}
+	}
+	if err := validateComplexityMode("ast"); err == nil {
+		t.Fatalf("expected unknown mode to fail validation")
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Temporal coupling.
+//
+// The coupling mode mines `git log --name-only` over the hotspot churn window
+// for files that change in the same commits. A pair is reported when it
+// co-changed in at least --coupling-min-support commits and, for at least one
+// side, the share of that file's commits that also touched the partner
+// (confidence) reaches --coupling-min-confidence. Commits touching more than
+// --coupling-max-files files (bulk renames, formatting sweeps) are ignored.
+
+const (
+	defaultCouplingOut           = "reports/coupling.sarif"
+	defaultCouplingMinSupport    = 3
+	defaultCouplingMinConfidence = 0.5
+	defaultCouplingMaxFiles      = 50
+	// maxCouplingPairs keeps reports reviewable on large histories.
+	maxCouplingPairs = 500
+
+	couplingRuleID         = "coupling"
+	couplingFingerprintKey = "punchtrunkCoupling/v1"
+)
+
+type couplingPair struct {
+	File              string  `json:"file"`
+	CoupledWith       string  `json:"coupled_with"`
+	Support           int     `json:"support"`
+	Confidence        float64 `json:"confidence"`
+	ReverseConfidence float64 `json:"reverse_confidence"`
+	FileCommits       int     `json:"file_commits"`
+}
+
+func (p couplingPair) describe() string {
+	return fmt.Sprintf("%s changes with %s in %.0f%% of its commits (%d of %d)",
+		p.File, p.CoupledWith, p.Confidence*100, p.Support, p.FileCommits)
+}
+
+type couplingReport struct {
+	Window        churnWindow    `json:"-"`
+	Commits       int            `json:"commits"`
+	SkippedLarge  int            `json:"skipped_large_commits"`
+	MinSupport    int            `json:"min_support"`
+	MinConfidence float64        `json:"min_confidence"`
+	MaxFiles      int            `json:"max_files_per_commit"`
+	Pairs         []couplingPair `json:"pairs"`
+}
+
+func runCoupling(ctx context.Context, cfg *Config) error {
+	report, err := analyzeCoupling(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(cfg.CouplingOut) == "" {
+		if cfg.Verbose {
+			cfg.log().Warnf("Coupling computed (%d pairs) but output path is empty", len(report.Pairs))
+		}
+		return nil
+	}
+	out, err := prepareReportPath(cfg, cfg.CouplingOut)
+	if err != nil {
+		return err
+	}
+	cfg.CouplingOut = out
+	if strings.EqualFold(filepath.Ext(out), ".json") {
+		err = writeCouplingJSON(out, report)
+	} else {
+		err = writeCouplingSARIF(out, report)
+	}
+	if err != nil {
+		return err
+	}
+	cfg.log().Event("info", "coupling.write", LogFields{
+		"coupling_out": out,
+		"count":        len(report.Pairs),
+		"commits":      report.Commits,
+	})
+	return nil
+}
+
+func (cfg *Config) couplingThresholds() (minSupport int, minConfidence float64, maxFiles int, err error) {
+	minSupport, minConfidence, maxFiles = defaultCouplingMinSupport, defaultCouplingMinConfidence, defaultCouplingMaxFiles
+	if cfg != nil {
+		if cfg.CouplingMinSupport != 0 {
+			minSupport = cfg.CouplingMinSupport
+		}
+		if cfg.CouplingMinConfidence != 0 {
+			minConfidence = cfg.CouplingMinConfidence
+		}
+		if cfg.CouplingMaxFiles != 0 {
+			maxFiles = cfg.CouplingMaxFiles
+		}
+	}
+	switch {
+	case minSupport < 1:
+		err = fmt.Errorf("invalid coupling-min-support %d: must be at least 1", minSupport)
+	case minConfidence <= 0 || minConfidence > 1:
+		err = fmt.Errorf("invalid coupling-min-confidence %.2f: must be in (0, 1]", minConfidence)
+	case maxFiles < 2:
+		err = fmt.Errorf("invalid coupling-max-files %d: must be at least 2", maxFiles)
+	}
+	return minSupport, minConfidence, maxFiles, err
+}
+
+func analyzeCoupling(ctx context.Context, cfg *Config) (*couplingReport, error) {
+	window := cfg.churnWindow()
+	if err := window.validate(); err != nil {
+		return nil, err
+	}
+	minSupport, minConfidence, maxFiles, err := cfg.couplingThresholds()
+	if err != nil {
+		return nil, err
+	}
+	history, err := cfg.history()
+	if err != nil {
+		return nil, err
+	}
+	commits, degraded, err := history.log(ctx, window, logNameOnly)
+	if err != nil {
+		return nil, err
+	}
+	cfg.hotspotCache().flush(cfg)
+	if degraded && cfg.Verbose {
+		cfg.log().Infof("falling back to limited git history for coupling; pairs may be partial")
+	}
+	counts := map[string]int{}
+	for _, c := range commits {
+		if len(c.Files) > maxFiles {
+			continue
+		}
+		for _, f := range c.Files {
+			counts[f.Path]++
+		}
+	}
+	filter := newHotspotFilter(cfg)
+	filter.apply(ctx, counts, nil)
+	for f := range counts {
+		if _, err := os.Stat(f); err != nil {
+			delete(counts, f)
+		}
+	}
+	pairs, skipped := coChangePairs(commits, counts, maxFiles)
+	report := &couplingReport{
+		Window:        window,
+		Commits:       len(commits),
+		SkippedLarge:  skipped,
+		MinSupport:    minSupport,
+		MinConfidence: minConfidence,
+		MaxFiles:      maxFiles,
+		Pairs:         couplingFromCounts(pairs, counts, minSupport, minConfidence),
+	}
+	return report, nil
+}
+
+// parseNameOnlyCommits reads `git log --name-only` output written with
+// numstatFormat headers.
+func parseNameOnlyCommits(output string) []numstatCommit {
+	var commits []numstatCommit
+	current := -1
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, numstatCommitMarker) {
+			commits = append(commits, parseNumstatHeader(strings.TrimPrefix(line, numstatCommitMarker)))
+			current = len(commits) - 1
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if current < 0 {
+			commits = append(commits, numstatCommit{})
+			current = 0
+		}
+		commits[current].Files = append(commits[current].Files, numstatFile{Path: line})
+	}
+	return commits
+}
+
+// coChangePairs counts commits shared by each pair of tracked files, keyed by
+// the sorted pair. Commits above maxFiles are skipped and counted.
+func coChangePairs(commits []numstatCommit, tracked map[string]int, maxFiles int) (map[[2]string]int, int) {
+	pairs := map[[2]string]int{}
+	skipped := 0
+	for _, c := range commits {
+		if len(c.Files) > maxFiles {
+			skipped++
+			continue
+		}
+		var files []string
+		seen := map[string]bool{}
+		for _, f := range c.Files {
+			if _, ok := tracked[f.Path]; ok && !seen[f.Path] {
+				seen[f.Path] = true
+				files = append(files, f.Path)
+			}
+		}
+		sort.Strings(files)
+		for i := 0; i < len(files); i++ {
+			for j := i + 1; j < len(files); j++ {
+				pairs[[2]string{files[i], files[j]}]++
+			}
+		}
+	}
+	return pairs, skipped
+}
+
+// couplingFromCounts applies the support and confidence thresholds. Each pair
+// is reported from the side with the higher confidence, strongest first.
+func couplingFromCounts(pairs map[[2]string]int, commitsPerFile map[string]int, minSupport int, minConfidence float64) []couplingPair {
+	var out []couplingPair
+	for key, support := range pairs {
+		if support < minSupport {
+			continue
+		}
+		a, b := key[0], key[1]
+		confA := float64(support) / float64(commitsPerFile[a])
+		confB := float64(support) / float64(commitsPerFile[b])
+		p := couplingPair{File: a, CoupledWith: b, Support: support, Confidence: confA, ReverseConfidence: confB, FileCommits: commitsPerFile[a]}
+		if confB > confA {
+			p = couplingPair{File: b, CoupledWith: a, Support: support, Confidence: confB, ReverseConfidence: confA, FileCommits: commitsPerFile[b]}
+		}
+		if p.Confidence < minConfidence {
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		if out[i].Support != out[j].Support {
+			return out[i].Support > out[j].Support
+		}
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].CoupledWith < out[j].CoupledWith
+	})
+	if len(out) > maxCouplingPairs {
+		out = out[:maxCouplingPairs]
+	}
+	return out
+}
+
+func couplingRule() SarifReportingDescriptor {
+	return SarifReportingDescriptor{
+		ID:               couplingRuleID,
+		Name:             "TemporalCoupling",
+		ShortDescription: &SarifMessage{Text: "Files that usually change together"},
+		FullDescription:  &SarifMessage{Text: "Pairs of files that co-change in many commits. Frequent co-change without an explicit dependency often signals hidden coupling."},
+		Help: &SarifMultiformatMessage{
+			Text:     "Check whether the pair should share an abstraction, live together, or be decoupled. Confidence is the share of the file's commits that also changed its partner.",
+			Markdown: "Check whether the pair should share an abstraction, live together, or be decoupled.\n\n`confidence` is the share of the file's commits that also changed its partner; `support` is the number of shared commits. See [Hotspots Methodology](" + hotspotHelpURI + ").",
+		},
+		HelpURI:              hotspotHelpURI,
+		DefaultConfiguration: &SarifRuleConfiguration{Level: "note"},
+		Properties:           map[string]any{"tags": []string{"maintainability", "coupling"}},
+	}
+}
+
+func buildCouplingSARIF(report *couplingReport) SarifLog {
+	log := SarifLog{
+		Version: "2.1.0",
+		Schema:  sarifSchemaURI,
+		Runs: []SarifRun{{
+			Tool: SarifTool{Driver: SarifDriver{
+				Name:           "PunchTrunk",
+				Version:        Version,
+				InformationURI: "https://docs.trunk.io/",
+				Rules:          []SarifReportingDescriptor{couplingRule()},
+			}},
+			Results: []SarifResult{},
+			Properties: map[string]any{
+				"churn_window":          report.Window.properties(),
+				"commits":               report.Commits,
+				"skipped_large_commits": report.SkippedLarge,
+				"min_support":           report.MinSupport,
+				"min_confidence":        report.MinConfidence,
+				"max_files_per_commit":  report.MaxFiles,
+			},
+		}},
+	}
+	for _, p := range report.Pairs {
+		uri := filepath.ToSlash(p.File)
+		partner := filepath.ToSlash(p.CoupledWith)
+		sum := sha256.Sum256([]byte(uri + "\x00" + partner))
+		ruleIndex := 0
+		log.Runs[0].Results = append(log.Runs[0].Results, SarifResult{
+			RuleID:    couplingRuleID,
+			RuleIndex: &ruleIndex,
+			Level:     "note",
+			Message:   SarifMessage{Text: p.describe()},
+			Locations: []SarifLocation{{
+				PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: SarifArtifactLocation{URI: uri}},
+			}},
+			RelatedLocations: []SarifLocation{{
+				ID:               1,
+				PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: SarifArtifactLocation{URI: partner}},
+				Message:          &SarifMessage{Text: "co-changed file"},
+			}},
+			PartialFingerprints: map[string]string{couplingFingerprintKey: hex.EncodeToString(sum[:])},
+			Properties: map[string]any{
+				"coupled_with":       partner,
+				"support":            p.Support,
+				"confidence":         math.Round(p.Confidence*1000) / 1000,
+				"reverse_confidence": math.Round(p.ReverseConfidence*1000) / 1000,
+				"file_commits":       p.FileCommits,
+			},
+		})
+	}
+	return log
+}
+
+func writeCouplingSARIF(path string, report *couplingReport) error {
+	log := buildCouplingSARIF(report)
+	return writeIndentedJSON(path, &log)
+}
+
+func writeCouplingJSON(path string, report *couplingReport) error {
+	doc := struct {
+		ChurnWindow map[string]any `json:"churn_window"`
+		*couplingReport
+	}{report.Window.properties(), report}
+	if doc.Pairs == nil {
+		doc.Pairs = []couplingPair{}
+	}
+	return writeIndentedJSON(path, &doc)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseNameOnlyCommits(t *testing.T) {
+	output := "\x1eaaa 1700000000 a@example.com\n\nhandler.go\nschema.sql\n\x1ebbb 1690000000 b@example.com\n\nhandler.go\n"
+	commits := parseNameOnlyCommits(output)
+	if len(commits) != 2 || len(commits[0].Files) != 2 || commits[1].Files[0].Path != "handler.go" {
+		t.Fatalf("unexpected commits: %+v", commits)
+	}
+}
+
+func TestCouplingFromCounts(t *testing.T) {
+	commits := []numstatCommit{
+		{Files: []numstatFile{{Path: "handler.go"}, {Path: "schema.sql"}}},
+		{Files: []numstatFile{{Path: "handler.go"}, {Path: "schema.sql"}, {Path: "readme.md"}}},
+		{Files: []numstatFile{{Path: "handler.go"}, {Path: "schema.sql"}}},
+		{Files: []numstatFile{{Path: "schema.sql"}}},
+		{Files: []numstatFile{{Path: "readme.md"}}},
+	}
+	counts := map[string]int{"handler.go": 3, "schema.sql": 4, "readme.md": 2}
+	pairs, skipped := coChangePairs(commits, counts, 2)
+	if skipped != 1 {
+		t.Fatalf("expected the three-file commit to be skipped, got %d", skipped)
+	}
+	got := couplingFromCounts(pairs, counts, 2, 0.5)
+	if len(got) != 1 {
+		t.Fatalf("expected one coupled pair, got %+v", got)
+	}
+	p := got[0]
+	if p.File != "handler.go" || p.CoupledWith != "schema.sql" || p.Support != 2 || p.Confidence != 2.0/3.0 || p.ReverseConfidence != 0.5 {
+		t.Fatalf("unexpected pair: %+v", p)
+	}
+	if len(couplingFromCounts(pairs, counts, 3, 0.5)) != 0 {
+		t.Fatalf("expected support threshold to drop the pair")
+	}
+}
+
+func TestConfigCouplingThresholds(t *testing.T) {
+	support, confidence, maxFiles, err := (&Config{}).couplingThresholds()
+	if err != nil || support != defaultCouplingMinSupport || confidence != defaultCouplingMinConfidence || maxFiles != defaultCouplingMaxFiles {
+		t.Fatalf("unexpected defaults: %d %f %d %v", support, confidence, maxFiles, err)
+	}
+	for _, cfg := range []*Config{{CouplingMinSupport: -1}, {CouplingMinConfidence: 1.5}, {CouplingMaxFiles: 1}} {
+		if _, _, _, err := cfg.couplingThresholds(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestRunCouplingWritesReports(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "other.go", "package a\n")
+	for i := 0; i < 4; i++ {
+		writeFile(t, repo, "handler.go", fmt.Sprintf("package a\n\nvar v = %d\n", i))
+		writeFile(t, repo, "schema.sql", fmt.Sprintf("-- rev %d\n", i))
+		gitAddCommit(t, repo, fmt.Sprintf("change %d", i))
+	}
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{Timeout: 10 * time.Second, CouplingOut: filepath.Join(repo, "reports", "coupling.sarif")}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if err := runCoupling(context.Background(), cfg); err != nil {
+		t.Fatalf("runCoupling: %v", err)
+	}
+	data, err := os.ReadFile(cfg.CouplingOut)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != "coupling" || len(results[0].RelatedLocations) != 1 {
+		t.Fatalf("expected one coupling result, got %+v", results)
+	}
+	if results[0].Properties["support"] != float64(4) || results[0].Properties["confidence"] != float64(1) {
+		t.Fatalf("unexpected coupling properties: %v", results[0].Properties)
+	}
+
+	cfg.CouplingOut = filepath.Join(repo, "reports", "coupling.json")
+	if err := runCoupling(context.Background(), cfg); err != nil {
+		t.Fatalf("runCoupling json: %v", err)
+	}
+	data, err = os.ReadFile(cfg.CouplingOut)
+	if err != nil {
+		t.Fatalf("read JSON: %v", err)
+	}
+	var doc struct {
+		Commits int            `json:"commits"`
+		Pairs   []couplingPair `json:"pairs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal JSON: %v", err)
+	}
+	if doc.Commits != 4 || len(doc.Pairs) != 1 || doc.Pairs[0].Support != 4 {
+		t.Fatalf("unexpected JSON report: %+v", doc)
+	}
+}
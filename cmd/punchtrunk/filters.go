@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hotspot path filtering.
+//
+// Files are dropped from hotspot rankings when they match --hotspot-exclude,
+// miss every --hotspot-include pattern, are marked linguist-generated or
+// linguist-vendored in .gitattributes, carry a "Code generated ... DO NOT
+// EDIT" header, or are binary. Generated/vendored/binary detection can be
+// disabled with --hotspot-include-generated.
+
+const (
+	skipReasonExcluded  = "excluded"
+	skipReasonVendored  = "vendored"
+	skipReasonGenerated = "generated"
+	skipReasonBinary    = "binary"
+
+	// sniffBytes bounds how much of each file is read for header and binary checks.
+	sniffBytes = 8000
+)
+
+var generatedHeaderPattern = regexp.MustCompile(`(?m)^\s*(//|#|/\*|\*|--|<!--|;)?\s*Code generated\b.*\bDO NOT EDIT\b`)
+
+type hotspotFilter struct {
+	include         []*regexp.Regexp
+	exclude         []*regexp.Regexp
+	includePatterns []string
+	excludePatterns []string
+	detectGenerated bool
+}
+
+func newHotspotFilter(cfg *Config) *hotspotFilter {
+	f := &hotspotFilter{detectGenerated: true}
+	if cfg == nil {
+		return f
+	}
+	f.detectGenerated = !cfg.HotspotIncludeGenerated
+	for _, raw := range cfg.HotspotInclude {
+		for _, p := range splitCSV(raw) {
+			f.includePatterns = append(f.includePatterns, p)
+			f.include = append(f.include, globToRegexp(p))
+		}
+	}
+	for _, raw := range cfg.HotspotExclude {
+		for _, p := range splitCSV(raw) {
+			f.excludePatterns = append(f.excludePatterns, p)
+			f.exclude = append(f.exclude, globToRegexp(p))
+		}
+	}
+	return f
+}
+
+// apply removes filtered paths from churn and returns skip counts per reason.
+// binary lists paths git numstat reported as binary.
+func (f *hotspotFilter) apply(ctx context.Context, churn map[string]int, binary map[string]bool) map[string]int {
+	skipped := map[string]int{}
+	var candidates []string
+	for file := range churn {
+		if !f.pathAllowed(file) {
+			delete(churn, file)
+			skipped[skipReasonExcluded]++
+			continue
+		}
+		candidates = append(candidates, file)
+	}
+	if !f.detectGenerated || len(candidates) == 0 {
+		return skipped
+	}
+	sort.Strings(candidates)
+	attrs := gitLinguistAttributes(ctx, candidates)
+	for _, file := range candidates {
+		reason := ""
+		switch {
+		case attrs[file][skipReasonVendored]:
+			reason = skipReasonVendored
+		case attrs[file][skipReasonGenerated]:
+			reason = skipReasonGenerated
+		default:
+			reason = sniffFile(file, binary[file])
+		}
+		if reason != "" {
+			delete(churn, file)
+			skipped[reason]++
+		}
+	}
+	return skipped
+}
+
+func (f *hotspotFilter) pathAllowed(file string) bool {
+	file = path.Clean(strings.ReplaceAll(file, "\\", "/"))
+	for _, re := range f.exclude {
+		if re.MatchString(file) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *hotspotFilter) properties(skipped map[string]int) map[string]any {
+	props := map[string]any{
+		"skip_generated": f.detectGenerated,
+	}
+	if len(f.includePatterns) > 0 {
+		props["include"] = f.includePatterns
+	}
+	if len(f.excludePatterns) > 0 {
+		props["exclude"] = f.excludePatterns
+	}
+	if len(skipped) > 0 {
+		props["skipped"] = skipped
+	}
+	return props
+}
+
+// sniffFile reports "generated" or "binary" based on file contents. Files
+// that cannot be read fall back to git's binary classification.
+func sniffFile(file string, numstatBinary bool) string {
+	fh, err := os.Open(file)
+	if err != nil {
+		if numstatBinary {
+			return skipReasonBinary
+		}
+		return ""
+	}
+	defer fh.Close()
+	head := make([]byte, sniffBytes)
+	n, _ := io.ReadFull(fh, head)
+	head = head[:n]
+	if bytes.IndexByte(head, 0) >= 0 || (numstatBinary && n == 0) {
+		return skipReasonBinary
+	}
+	if generatedHeaderPattern.Match(head) {
+		return skipReasonGenerated
+	}
+	if numstatBinary {
+		return skipReasonBinary
+	}
+	return ""
+}
+
+// gitLinguistAttributes resolves linguist-generated/linguist-vendored for the
+// given paths with one `git check-attr` call so nested .gitattributes files
+// and macro attributes follow git's own rules.
+func gitLinguistAttributes(ctx context.Context, files []string) map[string]map[string]bool {
+	out := map[string]map[string]bool{}
+	var stdin bytes.Buffer
+	for _, f := range files {
+		stdin.WriteString(f)
+		stdin.WriteByte(0)
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "check-attr", "-z", "--stdin", "linguist-generated", "linguist-vendored")
+	cmd.Stdin = &stdin
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return out
+	}
+	fields := strings.Split(stdout.String(), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		file, attr, value := fields[i], fields[i+1], fields[i+2]
+		if value != "set" && value != "true" {
+			continue
+		}
+		if out[file] == nil {
+			out[file] = map[string]bool{}
+		}
+		out[file][strings.TrimPrefix(attr, "linguist-")] = true
+	}
+	return out
+}
+
+// globToRegexp compiles a gitignore-style glob: `*` and `?` stay within a
+// path segment, `**` spans directories, a pattern that names a directory
+// also matches everything below it, and patterns without a slash match at
+// any depth.
+func globToRegexp(pattern string) *regexp.Regexp {
+	pattern = strings.TrimSpace(strings.ReplaceAll(pattern, "\\", "/"))
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/**"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"*.pb.go", "api/v1/service.go", false},
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"vendor/", "internal/vendor/z.go", true},
+		{"/vendor/", "internal/vendor/z.go", false},
+		{"cmd/*.go", "cmd/main.go", true},
+		{"cmd/*.go", "cmd/sub/main.go", false},
+		{"cmd/**/*.go", "cmd/sub/deep/main.go", true},
+		{"cmd/**/*.go", "cmd/main.go", true},
+		{"**/__snapshots__/**", "web/src/__snapshots__/app.snap", true},
+		{"package-lock.json", "web/package-lock.json", true},
+		{"docs/?.md", "docs/a.md", true},
+		{"docs/?.md", "docs/ab.md", false},
+		{"vendor", "vendor/github.com/x/y.go", true},
+		{"vendor", "internal/vendor/z.go", true},
+		{"vendor", "vendored/z.go", false},
+		{"docs/api", "docs/api/index.md", true},
+		{"docs/api", "docs/api", true},
+		{"docs/api", "docs/apis/index.md", false},
+		{"docs/api", "web/docs/api/index.md", false},
+	}
+	for _, tt := range tests {
+		if got := globToRegexp(tt.pattern).MatchString(tt.path); got != tt.want {
+			t.Errorf("glob %q vs %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHotspotFilterIncludeExclude(t *testing.T) {
+	f := newHotspotFilter(&Config{
+		HotspotInclude:          []string{"cmd/**,internal/**"},
+		HotspotExclude:          []string{"*_test.go"},
+		HotspotIncludeGenerated: true,
+	})
+	churn := map[string]int{
+		"cmd/app/main.go":      10,
+		"cmd/app/main_test.go": 4,
+		"internal/db/db.go":    3,
+		"docs/readme.md":       2,
+	}
+	skipped := f.apply(context.Background(), churn, nil)
+	if len(churn) != 2 || churn["cmd/app/main.go"] == 0 || churn["internal/db/db.go"] == 0 {
+		t.Fatalf("unexpected remaining churn: %v", churn)
+	}
+	if skipped[skipReasonExcluded] != 2 {
+		t.Fatalf("expected 2 excluded paths, got %v", skipped)
+	}
+}
+
+func TestComputeHotspotsSkipsGeneratedVendoredAndBinary(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, ".gitattributes", "third_party/** linguist-vendored\nschema.sql linguist-generated=true\n")
+	writeFile(t, repo, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, repo, "api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n")
+	writeFile(t, repo, "schema.sql", "create table t (id int);\n")
+	if err := os.MkdirAll(filepath.Join(repo, "third_party"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, repo, "third_party/lib.go", "package lib\n")
+	if err := os.WriteFile(filepath.Join(repo, "logo.png"), []byte{0x89, 0x50, 0x00, 0x01}, 0o644); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+	gitAddCommit(t, repo, "initial")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{BaseBranch: "HEAD", Timeout: 10 * time.Second}
+	report, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	files := map[string]bool{}
+	for _, h := range report.Hotspots {
+		files[h.File] = true
+	}
+	for _, skippedFile := range []string{"api.pb.go", "schema.sql", "third_party/lib.go", "logo.png"} {
+		if files[skippedFile] {
+			t.Errorf("expected %s to be skipped, got %v", skippedFile, files)
+		}
+	}
+	if !files["main.go"] {
+		t.Fatalf("expected main.go to remain, got %v", files)
+	}
+	skipped, _ := report.Filters["skipped"].(map[string]int)
+	if skipped[skipReasonGenerated] != 2 || skipped[skipReasonVendored] != 1 || skipped[skipReasonBinary] != 1 {
+		t.Fatalf("unexpected skip counts: %v", report.Filters)
+	}
+
+	cfg.HotspotIncludeGenerated = true
+	all, err := computeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("computeHotspots: %v", err)
+	}
+	if len(all) != 6 {
+		t.Fatalf("expected every file when generated detection is disabled, got %d", len(all))
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Hotspot output formats.
+//
+// --hotspot-format selects one or more of sarif, json, csv and markdown. SARIF
+// goes to --sarif-out; the other formats are written next to it with their
+// own extension (reports/hotspots.csv, ...) unless a dedicated path flag such
+// as --hotspot-json is set. Every path goes through prepareReportPath, so a
+// read-only checkout falls back to <tmp>/punchtrunk/reports like SARIF does.
+
+const (
+	hotspotFormatSARIF    = "sarif"
+	hotspotFormatJSON     = "json"
+	hotspotFormatCSV      = "csv"
+	hotspotFormatMarkdown = "markdown"
+
+	defaultHotspotFormat = hotspotFormatSARIF
+	// defaultHotspotReportBase names derived reports when --sarif-out is empty.
+	defaultHotspotReportBase = "reports/hotspots"
+	// maxMarkdownRows keeps the markdown table short enough for PR comments.
+	maxMarkdownRows = 50
+)
+
+var hotspotFormatExtensions = map[string]string{
+	hotspotFormatSARIF:    ".sarif",
+	hotspotFormatJSON:     ".json",
+	hotspotFormatCSV:      ".csv",
+	hotspotFormatMarkdown: ".md",
+}
+
+// hotspotCSVColumns is the stable CSV header. Append new columns at the end so
+// existing notebooks keep working.
+var hotspotCSVColumns = []string{
+	"rank", "file", "churn", "complexity", "score", "changed",
+	"percentile", "complexity_z", "decayed_churn", "cognitive_complexity",
+	"authors", "top_author_share", "bus_factor", "knowledge_risk", "owners",
+	"trend", "score_delta",
+}
+
+func parseHotspotFormats(raw string) ([]string, error) {
+	entries := splitCSV(raw)
+	if len(entries) == 0 {
+		return []string{defaultHotspotFormat}, nil
+	}
+	seen := map[string]bool{}
+	var formats []string
+	for _, f := range entries {
+		f = strings.ToLower(f)
+		if f == "md" {
+			f = hotspotFormatMarkdown
+		}
+		if _, ok := hotspotFormatExtensions[f]; !ok {
+			return nil, fmt.Errorf("unsupported hotspot-format %q (expected sarif, json, csv or markdown)", f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			formats = append(formats, f)
+		}
+	}
+	return formats, nil
+}
+
+// hotspotOutputPath derives a sibling of --sarif-out with the format's
+// extension.
+func hotspotOutputPath(sarifOut, format string) string {
+	base := strings.TrimSpace(sarifOut)
+	if base == "" {
+		base = defaultHotspotReportBase
+	} else {
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return base + hotspotFormatExtensions[format]
+}
+
+type hotspotOutput struct {
+	kind  string
+	path  *string
+	write func(string, *hotspotReport) error
+}
+
+// hotspotOutputs lists the reports to write. SARIF stays bound to
+// cfg.SarifOut so fallback redirects are visible to callers.
+func hotspotOutputs(cfg *Config, formats []string) []hotspotOutput {
+	var outputs []hotspotOutput
+	for _, format := range formats {
+		switch format {
+		case hotspotFormatSARIF:
+			outputs = append(outputs, hotspotOutput{hotspotFormatSARIF, &cfg.SarifOut, writeHotspotSARIF})
+		case hotspotFormatJSON:
+			if strings.TrimSpace(cfg.HotspotJSON) == "" {
+				cfg.HotspotJSON = hotspotOutputPath(cfg.SarifOut, format)
+			}
+		case hotspotFormatCSV:
+			path := hotspotOutputPath(cfg.SarifOut, format)
+			outputs = append(outputs, hotspotOutput{hotspotFormatCSV, &path, writeHotspotCSV})
+		case hotspotFormatMarkdown:
+			path := hotspotOutputPath(cfg.SarifOut, format)
+			outputs = append(outputs, hotspotOutput{hotspotFormatMarkdown, &path, writeHotspotMarkdown})
+		}
+	}
+	outputs = append(outputs,
+		hotspotOutput{hotspotFormatJSON, &cfg.HotspotJSON, writeHotspotJSON},
+		hotspotOutput{"treemap", &cfg.HotspotTreemap, writeHotspotTreemap},
+		hotspotOutput{"html", &cfg.HotspotHTML, writeHotspotHTML},
+	)
+	return outputs
+}
+
+func writeHotspotReports(cfg *Config, report *hotspotReport, outputs []hotspotOutput) error {
+	for _, o := range outputs {
+		if strings.TrimSpace(*o.path) == "" {
+			if o.kind == hotspotFormatSARIF && cfg.Verbose {
+				cfg.log().Warnf("Hotspots computed (%d results) but SARIF output path is empty", len(report.Hotspots))
+			}
+			continue
+		}
+		out, err := prepareReportPath(cfg, *o.path)
+		if err != nil {
+			return err
+		}
+		*o.path = out
+		if err := o.write(out, report); err != nil {
+			if o.kind == hotspotFormatSARIF {
+				return err
+			}
+			return fmt.Errorf("write hotspot %s report: %w", o.kind, err)
+		}
+		if o.kind == hotspotFormatSARIF {
+			cfg.log().Event("info", "sarif.write", LogFields{
+				"sarif_out": out,
+				"count":     len(report.Hotspots),
+			})
+			continue
+		}
+		cfg.log().Event("info", "report.write", LogFields{
+			"kind":  o.kind,
+			"path":  out,
+			"count": len(report.Hotspots),
+		})
+	}
+	return nil
+}
+
+func hotspotCSVRow(h Hotspot) []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	row := []string{
+		strconv.Itoa(h.Rank), filepath.ToSlash(h.File), strconv.Itoa(h.Churn),
+		f(h.Complexity), f(h.Score), strconv.FormatBool(h.Changed),
+		f(h.Percentile), f(h.ComplexityZ), f(h.DecayedChurn), f(h.Cognitive),
+		"", "", "", "", "", "", "",
+	}
+	if o := h.Ownership; o != nil {
+		row[10] = strconv.Itoa(o.Authors)
+		row[11] = f(o.TopAuthorShare)
+		row[12] = strconv.Itoa(o.BusFactor)
+		row[13] = f(o.KnowledgeRisk)
+		row[14] = strings.Join(o.Owners, " ")
+	}
+	if t := h.Trend; t != nil {
+		row[15] = t.State
+		row[16] = f(t.ScoreDelta)
+	}
+	return row
+}
+
+func writeHotspotCSV(path string, report *hotspotReport) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(hotspotCSVColumns); err != nil {
+		return err
+	}
+	for _, h := range report.Hotspots {
+		if err := w.Write(hotspotCSVRow(h)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func writeHotspotMarkdown(path string, report *hotspotReport) error {
+	var b strings.Builder
+	b.WriteString("## Hotspots\n\n")
+	if report.Window.Since != "" {
+		fmt.Fprintf(&b, "Churn window: %s.", report.Window.describe())
+		if report.Model.Name != "" {
+			fmt.Fprintf(&b, " Model: %s.", report.Model.describe())
+		}
+		b.WriteString("\n\n")
+	}
+	if report.Selection.changedOnly() {
+		fmt.Fprintf(&b, "%s\n\n", report.Selection.touchedSummary())
+	}
+	if report.Trend != nil {
+		t := report.Trend
+		fmt.Fprintf(&b, "Compared with `%s`: %d new, %d rising, %d cooling, %d steady, %d resolved.\n\n",
+			t.Baseline, t.New, t.Rising, t.Cooling, t.Steady, t.Resolved)
+	}
+	if len(report.Hotspots) == 0 {
+		b.WriteString("No hotspots found.\n")
+		writeMarkdownSelection(&b, report.Selection)
+		return os.WriteFile(path, []byte(b.String()), 0o644)
+	}
+	b.WriteString("| Rank | Percentile | File | Churn | Complexity | Score | Changed |\n")
+	b.WriteString("| ---: | ---------: | ---- | ----: | ---------: | ----: | :-----: |\n")
+	for i, h := range report.Hotspots {
+		if i == maxMarkdownRows {
+			break
+		}
+		changed := ""
+		if h.Changed {
+			changed = "yes"
+		}
+		file := strings.ReplaceAll(filepath.ToSlash(h.File), "|", "\\|")
+		fmt.Fprintf(&b, "| %d | %.0f | `%s` | %d | %.2f | %.2f | %s |\n", h.Rank, h.Percentile, file, h.Churn, h.Complexity, h.Score, changed)
+	}
+	if len(report.Hotspots) > maxMarkdownRows {
+		fmt.Fprintf(&b, "\n_Showing the top %d of %d hotspots._\n", maxMarkdownRows, len(report.Hotspots))
+	}
+	writeMarkdownSelection(&b, report.Selection)
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+func writeMarkdownSelection(b *strings.Builder, s *hotspotSelection) {
+	if s == nil || s.droppedTotal() == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n_%d of %d ranked files not reported: %s._\n", s.droppedTotal(), s.Ranked, s.describeDropped())
+}
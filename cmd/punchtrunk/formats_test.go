@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHotspotFormats(t *testing.T) {
+	got, err := parseHotspotFormats("SARIF, csv,md,csv")
+	if err != nil {
+		t.Fatalf("parseHotspotFormats: %v", err)
+	}
+	if want := []string{"sarif", "csv", "markdown"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, _ := parseHotspotFormats(""); !reflect.DeepEqual(got, []string{"sarif"}) {
+		t.Fatalf("expected SARIF by default, got %v", got)
+	}
+	if _, err := parseHotspotFormats("xml"); err == nil {
+		t.Fatalf("expected unknown format to be rejected")
+	}
+}
+
+func TestHotspotOutputPath(t *testing.T) {
+	cases := map[[2]string]string{
+		{"reports/hotspots.sarif", "csv"}:      "reports/hotspots.csv",
+		{"out/custom.sarif.json", "markdown"}:  "out/custom.sarif.md",
+		{"", "json"}:                           "reports/hotspots.json",
+		{"reports/hotspots", hotspotFormatCSV}: "reports/hotspots.csv",
+	}
+	for in, want := range cases {
+		if got := hotspotOutputPath(in[0], in[1]); got != want {
+			t.Errorf("hotspotOutputPath(%q, %q) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
+
+func TestWriteHotspotCSVStableColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hotspots.csv")
+	report := &hotspotReport{Hotspots: []Hotspot{
+		{File: "a.go", Churn: 3, Complexity: 2.5, Score: 1.25, Rank: 1, Changed: true,
+			Ownership: &fileOwnership{Authors: 2, Owners: []string{"@a", "@b"}}},
+		{File: "b,c.go", Churn: 1, Rank: 2, Trend: &hotspotTrend{State: trendNew}},
+	}}
+	if err := writeHotspotCSV(path, report); err != nil {
+		t.Fatalf("writeHotspotCSV: %v", err)
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer fh.Close()
+	rows, err := csv.NewReader(fh).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[0][:6], []string{"rank", "file", "churn", "complexity", "score", "changed"}) {
+		t.Fatalf("unexpected header: %v", rows)
+	}
+	if !reflect.DeepEqual(rows[1][:6], []string{"1", "a.go", "3", "2.5", "1.25", "true"}) || rows[1][10] != "2" || rows[1][14] != "@a @b" {
+		t.Fatalf("unexpected first row: %v", rows[1])
+	}
+	if rows[2][1] != "b,c.go" || rows[2][15] != trendNew {
+		t.Fatalf("unexpected second row: %v", rows[2])
+	}
+}
+
+func TestRunHotspotsWritesMultipleFormats(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "main.go", "package main\n\nfunc main() {}\n")
+	gitAddCommit(t, repo, "initial")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{
+		BaseBranch:    "HEAD",
+		Timeout:       10 * time.Second,
+		SarifOut:      filepath.Join(repo, "reports", "hotspots.sarif"),
+		HotspotFormat: "json,csv,markdown",
+	}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if err := runHotspots(context.Background(), cfg); err != nil {
+		t.Fatalf("runHotspots: %v", err)
+	}
+	if _, err := os.Stat(cfg.SarifOut); !os.IsNotExist(err) {
+		t.Fatalf("expected no SARIF when it is not requested, got %v", err)
+	}
+	for _, name := range []string{"hotspots.json", "hotspots.csv", "hotspots.md"} {
+		if _, err := os.Stat(filepath.Join(repo, "reports", name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	md, err := os.ReadFile(filepath.Join(repo, "reports", "hotspots.md"))
+	if err != nil {
+		t.Fatalf("read markdown: %v", err)
+	}
+	if !strings.Contains(string(md), "| 1 | 100 | `main.go` |") {
+		t.Fatalf("expected markdown table row, got:\n%s", md)
+	}
+}
+
+func TestRunHotspotsFormatsFallBackOnReadOnlyWorkspace(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "main.go", "package main\n\nfunc main() {}\n")
+	gitAddCommit(t, repo, "initial")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+	readonlyRoot := filepath.Join(repo, "readonly")
+	if err := os.Mkdir(readonlyRoot, 0o555); err != nil {
+		t.Fatalf("mkdir readonly: %v", err)
+	}
+	defer func() {
+		_ = os.Chmod(readonlyRoot, 0o755)
+	}()
+
+	customTmp := filepath.Join(repo, "custom-tmp")
+	cfg := &Config{
+		BaseBranch:    "HEAD",
+		Timeout:       10 * time.Second,
+		SarifOut:      filepath.Join(readonlyRoot, "subdir", "hotspots.sarif"),
+		HotspotFormat: "sarif,csv",
+		TmpDir:        customTmp,
+	}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if err := runHotspots(context.Background(), cfg); err != nil {
+		t.Fatalf("runHotspots: %v", err)
+	}
+	for _, name := range []string{"hotspots.sarif", "hotspots.csv"} {
+		if _, err := os.Stat(filepath.Join(customTmp, "punchtrunk", "reports", name)); err != nil {
+			t.Fatalf("expected fallback %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hotspot quality gate.
+//
+// Hotspot analysis never fails a run on its own. --hotspot-fail-on opts into
+// a gate that fails when a changed file scores at or above a threshold
+// (score=N), lands in the top N (top=N), or when the total hotspot score
+// rises more than a percentage over --hotspot-baseline (rise=P%). A failed
+// gate exits with exitCodeHotspotGate so CI can tell it apart from a trunk
+// check failure, which exits 1 via exitErr.
+
+const exitCodeHotspotGate = 3
+
+// hotspotGateErr records a failed hotspot gate; main exits with
+// exitCodeHotspotGate when it is set and trunk check passed.
+var hotspotGateErr error
+
+var errHotspotGate = errors.New("hotspot gate failed")
+
+type hotspotGate struct {
+	Spec     string
+	Score    float64
+	HasScore bool
+	Top      int
+	RisePct  float64
+	HasRise  bool
+}
+
+func parseHotspotGate(raw string) (*hotspotGate, error) {
+	entries := splitCSV(raw)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	g := &hotspotGate{Spec: strings.Join(entries, ",")}
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid hotspot-fail-on entry %q: expected score=N, top=N or rise=P%%", entry)
+		}
+		switch key {
+		case "score":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hotspot-fail-on score %q: %v", value, err)
+			}
+			g.Score, g.HasScore = v, true
+		case "top":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid hotspot-fail-on top %q: expected a positive integer", value)
+			}
+			g.Top = n
+		case "rise":
+			v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil || v < 0 {
+				return nil, fmt.Errorf("invalid hotspot-fail-on rise %q: expected a non-negative percentage", value)
+			}
+			g.RisePct, g.HasRise = v, true
+		default:
+			return nil, fmt.Errorf("unsupported hotspot-fail-on condition %q (expected score, top or rise)", key)
+		}
+	}
+	return g, nil
+}
+
+// evaluate returns one message per violated condition. score= and top= are
+// checked against the full ranking, not the reported selection; rise= sums
+// only files the baseline reported, so a truncated baseline is not compared
+// with a complete ranking.
+func (g *hotspotGate) evaluate(report *hotspotReport) []string {
+	if g == nil || report == nil {
+		return nil
+	}
+	ranked := report.rankedHotspots()
+	var violations []string
+	for _, h := range ranked {
+		if !h.Changed {
+			continue
+		}
+		if g.HasScore && h.Score >= g.Score {
+			violations = append(violations, fmt.Sprintf("%s scores %.2f (threshold %.2f)", h.File, h.Score, g.Score))
+		}
+		if g.Top > 0 && h.Rank > 0 && h.Rank <= g.Top {
+			violations = append(violations, fmt.Sprintf("%s ranks #%d (top %d)", h.File, h.Rank, g.Top))
+		}
+	}
+	if g.HasRise && report.Trend != nil && report.Trend.BaselineTotal > 0 {
+		current := report.Trend.CurrentTotal
+		rise := 100 * (current - report.Trend.BaselineTotal) / report.Trend.BaselineTotal
+		if rise > g.RisePct {
+			violations = append(violations, fmt.Sprintf("total hotspot score rose %.1f%% over baseline (%.2f -> %.2f, limit %.1f%%)", rise, report.Trend.BaselineTotal, current, g.RisePct))
+		}
+	}
+	return violations
+}
+
+// enforceHotspotGate evaluates --hotspot-fail-on and records failures in
+// hotspotGateErr. A gate that cannot be evaluated fails closed.
+func enforceHotspotGate(cfg *Config, report *hotspotReport, analysisErr error) {
+	if cfg == nil || strings.TrimSpace(cfg.HotspotFailOn) == "" {
+		return
+	}
+	gate, err := parseHotspotGate(cfg.HotspotFailOn)
+	if err == nil && analysisErr != nil {
+		err = fmt.Errorf("hotspot analysis failed: %w", analysisErr)
+	}
+	if err != nil {
+		hotspotGateErr = fmt.Errorf("%w: %v", errHotspotGate, err)
+		cfg.log().Event("error", "hotspots.gate", LogFields{"passed": false, "error": err.Error()})
+		return
+	}
+	if gate.HasRise && (report == nil || report.Trend == nil) {
+		cfg.log().Warnf("hotspot-fail-on rise=%.1f%% needs --hotspot-baseline; skipping that condition", gate.RisePct)
+	}
+	violations := gate.evaluate(report)
+	cfg.log().Event("info", "hotspots.gate", LogFields{
+		"passed":     len(violations) == 0,
+		"conditions": gate.Spec,
+		"violations": violations,
+	})
+	if len(violations) > 0 {
+		for _, v := range violations {
+			cfg.log().Errorf("hotspot gate: %s", v)
+		}
+		hotspotGateErr = fmt.Errorf("%w: %d violation(s)", errHotspotGate, len(violations))
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHotspotGate(t *testing.T) {
+	g, err := parseHotspotGate("score=7.5, top=10,rise=5%")
+	if err != nil {
+		t.Fatalf("parseHotspotGate: %v", err)
+	}
+	if !g.HasScore || g.Score != 7.5 || g.Top != 10 || !g.HasRise || g.RisePct != 5 {
+		t.Fatalf("unexpected gate: %+v", g)
+	}
+	if g, err := parseHotspotGate(""); err != nil || g != nil {
+		t.Fatalf("expected empty spec to disable the gate, got %+v, %v", g, err)
+	}
+	for _, bad := range []string{"score", "score=high", "top=0", "rise=-1", "owners=2"} {
+		if _, err := parseHotspotGate(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHotspotGateEvaluate(t *testing.T) {
+	report := &hotspotReport{
+		Hotspots: []Hotspot{
+			{File: "stable.go", Score: 9, Rank: 1},
+			{File: "touched.go", Score: 6, Rank: 2, Changed: true},
+			{File: "small.go", Score: 1, Rank: 3, Changed: true},
+		},
+		Trend: &trendSummary{BaselineTotal: 12, CurrentTotal: 16},
+	}
+	cases := []struct {
+		spec string
+		want int
+	}{
+		{"score=8", 0},
+		{"score=5", 1},
+		{"top=2", 1},
+		{"top=3,score=0.5", 4},
+		{"rise=50", 0},
+		{"rise=10%", 1},
+	}
+	for _, tc := range cases {
+		g, err := parseHotspotGate(tc.spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.spec, err)
+		}
+		if got := g.evaluate(report); len(got) != tc.want {
+			t.Errorf("%s: expected %d violation(s), got %v", tc.spec, tc.want, got)
+		}
+	}
+}
+
+func TestEnforceHotspotGate(t *testing.T) {
+	t.Cleanup(func() { hotspotGateErr = nil })
+	report := &hotspotReport{Hotspots: []Hotspot{{File: "a.go", Score: 4, Rank: 1, Changed: true}}}
+	cfg := &Config{HotspotFailOn: "top=5"}
+	cfg.logger = newEventLogger(io.Discard, false)
+
+	hotspotGateErr = nil
+	enforceHotspotGate(cfg, report, nil)
+	if !errors.Is(hotspotGateErr, errHotspotGate) {
+		t.Fatalf("expected gate failure, got %v", hotspotGateErr)
+	}
+
+	hotspotGateErr = nil
+	cfg.HotspotFailOn = "top=5,rise=1"
+	enforceHotspotGate(cfg, &hotspotReport{Hotspots: []Hotspot{{File: "a.go", Score: 4, Rank: 9, Changed: true}}}, nil)
+	if hotspotGateErr != nil {
+		t.Fatalf("expected gate to pass without baseline or top-5 changes, got %v", hotspotGateErr)
+	}
+
+	enforceHotspotGate(cfg, nil, errors.New("git missing"))
+	if !errors.Is(hotspotGateErr, errHotspotGate) {
+		t.Fatalf("expected gate to fail closed when analysis fails, got %v", hotspotGateErr)
+	}
+
+	hotspotGateErr = nil
+	enforceHotspotGate(&Config{}, report, nil)
+	if hotspotGateErr != nil {
+		t.Fatalf("expected no gate without --hotspot-fail-on, got %v", hotspotGateErr)
+	}
+}
+
+func TestHotspotGateChecksHotspotsBeyondLimit(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "hot.go", "package main\n\nfunc hot() {}\n")
+	writeFile(t, repo, "cold.go", "package main\n\nfunc cold() {}\n")
+	gitAddCommit(t, repo, "initial")
+	for i := 0; i < 3; i++ {
+		writeFile(t, repo, "hot.go", fmt.Sprintf("package main\n\nfunc hot() {\n\tif true {\n\t\tprintln(%d)\n\t}\n}\n", i))
+		gitAddCommit(t, repo, fmt.Sprintf("hot %d", i))
+	}
+	runGit(t, repo, "branch", "base")
+	writeFile(t, repo, "cold.go", "package main\n\nfunc cold() { println() }\n")
+	gitAddCommit(t, repo, "touch cold")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{BaseBranch: "base", Timeout: 10 * time.Second, HotspotLimit: 1}
+	cfg.logger = newEventLogger(io.Discard, false)
+	report, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	if len(report.Hotspots) != 1 || report.Hotspots[0].File != "hot.go" {
+		t.Fatalf("expected the limit to report only hot.go, got %+v", report.Hotspots)
+	}
+	g, err := parseHotspotGate("top=2")
+	if err != nil {
+		t.Fatalf("parseHotspotGate: %v", err)
+	}
+	violations := g.evaluate(report)
+	if len(violations) != 1 || !strings.Contains(violations[0], "cold.go ranks #2") {
+		t.Fatalf("expected the changed file beyond the limit to violate top=2, got %v", violations)
+	}
+}
+
+func TestHotspotGateRiseUsesFullRankingInChangedScope(t *testing.T) {
+	ranked := []Hotspot{
+		{File: "stable.go", Score: 9, Rank: 1},
+		{File: "touched.go", Score: 6, Rank: 2, Changed: true},
+	}
+	sel, err := (&Config{HotspotScope: hotspotScopeChanged}).hotspotSelection()
+	if err != nil {
+		t.Fatalf("hotspotSelection: %v", err)
+	}
+	baseline := map[string]baselineEntry{"stable.go": {Score: 7, Rank: 1}, "touched.go": {Score: 5, Rank: 2}}
+	report := &hotspotReport{
+		Trend:     applyHotspotBaseline(ranked, baseline, "old.sarif"),
+		Hotspots:  sel.apply(ranked),
+		Selection: sel,
+		ranked:    ranked,
+	}
+	if len(report.Hotspots) != 1 {
+		t.Fatalf("expected only the changed file to be reported, got %+v", report.Hotspots)
+	}
+	g, err := parseHotspotGate("rise=10%")
+	if err != nil {
+		t.Fatalf("parseHotspotGate: %v", err)
+	}
+	violations := g.evaluate(report)
+	if len(violations) != 1 || !strings.Contains(violations[0], "12.00 -> 15.00") {
+		t.Fatalf("expected a 25%% rise over the full ranking, got %v", violations)
+	}
+}
+
+func TestHotspotGateRiseWithTruncatedBaseline(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	for i := 0; i < 6; i++ {
+		writeFile(t, repo, fmt.Sprintf("f%d.go", i), fmt.Sprintf("package main\n\nfunc f%d() {}\n", i))
+	}
+	gitAddCommit(t, repo, "initial")
+	for i := 0; i < 6; i++ {
+		writeFile(t, repo, fmt.Sprintf("f%d.go", i), fmt.Sprintf("package main\n\nfunc f%d() {\n\tif true {\n\t\tprintln(%d)\n\t}\n}\n", i, i))
+		gitAddCommit(t, repo, fmt.Sprintf("touch f%d", i))
+	}
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{BaseBranch: "HEAD", Timeout: 10 * time.Second, HotspotLimit: 2}
+	cfg.logger = newEventLogger(io.Discard, false)
+	first, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	if !first.Selection.Truncated {
+		t.Fatalf("expected the baseline run to be truncated, got %+v", first.Selection)
+	}
+	baselinePath := filepath.Join(repo, "baseline.sarif")
+	if err := writeHotspotSARIF(baselinePath, first); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+
+	cfg = &Config{BaseBranch: "HEAD", Timeout: 10 * time.Second, HotspotBaseline: baselinePath}
+	cfg.logger = newEventLogger(io.Discard, false)
+	second, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots with baseline: %v", err)
+	}
+	g, err := parseHotspotGate("rise=5%")
+	if err != nil {
+		t.Fatalf("parseHotspotGate: %v", err)
+	}
+	if violations := g.evaluate(second); len(violations) != 0 {
+		t.Fatalf("expected no rise against a truncated baseline of the same HEAD, got %v", violations)
+	}
+	if second.Trend.CurrentTotal == 0 || math.Abs(second.Trend.CurrentTotal-second.Trend.BaselineTotal) > 0.01 {
+		t.Fatalf("expected matching totals over the baseline files, got %+v", second.Trend)
+	}
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Hotspot history backends.
+//
+// Churn, changed files and coupling read history through historySource. The
+// default "auto" backend reads the repository in-process with go-git, so
+// hotspots work in minimal containers without a git binary, and falls back to
+// the git CLI when go-git cannot serve a request (unsupported repository
+// layouts, approxidate windows such as "last monday"). --git-backend=cli or
+// go-git pins one backend. git blame and check-attr still use the CLI and are
+// skipped when it is missing.
+
+const (
+	gitBackendAuto  = "auto"
+	gitBackendGoGit = "go-git"
+	gitBackendCLI   = "cli"
+)
+
+// logMode selects what historySource.log reports per commit.
+type logMode int
+
+const (
+	// logNumstat reports per-file line counts with renames folded into
+	// current paths, like `git log --numstat --find-renames`.
+	logNumstat logMode = iota
+	// logNameOnly reports touched paths only, like `git log --name-only`.
+	logNameOnly
+)
+
+type historySource interface {
+	name() string
+	// changedFiles lists files in base...HEAD. degraded is true when base
+	// could not be resolved and a shorter diff was used.
+	changedFiles(ctx context.Context) (files map[string]bool, degraded bool, err error)
+	// log lists commits in the churn window, newest first.
+	log(ctx context.Context, window churnWindow, mode logMode) (commits []numstatCommit, degraded bool, err error)
+}
+
+var errUnsupportedHistoryBound = errors.New("unsupported history bound")
+
+func validateGitBackend(backend string) error {
+	switch backend {
+	case "", gitBackendAuto, gitBackendGoGit, gitBackendCLI:
+		return nil
+	}
+	return fmt.Errorf("unsupported git-backend %q (expected auto, go-git or cli)", backend)
+}
+
+// history resolves the configured backend.
+func (cfg *Config) history() (historySource, error) {
+	backend := gitBackendAuto
+	if cfg != nil && strings.TrimSpace(cfg.GitBackend) != "" {
+		backend = strings.ToLower(strings.TrimSpace(cfg.GitBackend))
+	}
+	if err := validateGitBackend(backend); err != nil {
+		return nil, err
+	}
+	cli := &cliHistory{cfg: cfg}
+	if backend == gitBackendCLI {
+		return cli, nil
+	}
+	_, lookErr := exec.LookPath("git")
+	inProcess, err := openGoGitHistory(cfg)
+	switch {
+	case err != nil && backend == gitBackendAuto && lookErr == nil:
+		if cfg != nil && cfg.Verbose {
+			cfg.log().Infof("go-git could not open the repository (%v); using the git CLI", err)
+		}
+		return cli, nil
+	case err != nil:
+		return nil, fmt.Errorf("open repository with go-git: %w", err)
+	case backend == gitBackendAuto && lookErr == nil:
+		return &fallbackHistory{primary: inProcess, fallback: cli, cfg: cfg}, nil
+	}
+	return inProcess, nil
+}
+
+// fallbackHistory retries failed primary reads with the fallback backend.
+type fallbackHistory struct {
+	primary  historySource
+	fallback historySource
+	cfg      *Config
+}
+
+func (h *fallbackHistory) name() string { return h.primary.name() }
+
+func (h *fallbackHistory) changedFiles(ctx context.Context) (map[string]bool, bool, error) {
+	files, degraded, err := h.primary.changedFiles(ctx)
+	if err == nil {
+		return files, degraded, nil
+	}
+	h.logFallback("changed files", err)
+	return h.fallback.changedFiles(ctx)
+}
+
+func (h *fallbackHistory) log(ctx context.Context, window churnWindow, mode logMode) ([]numstatCommit, bool, error) {
+	commits, degraded, err := h.primary.log(ctx, window, mode)
+	if err == nil {
+		return commits, degraded, nil
+	}
+	h.logFallback("history", err)
+	return h.fallback.log(ctx, window, mode)
+}
+
+func (h *fallbackHistory) logFallback(what string, err error) {
+	if h.cfg != nil && h.cfg.Verbose {
+		h.cfg.log().Infof("%s read %s failed: %v; retrying with %s", h.primary.name(), what, err, h.fallback.name())
+	}
+}
+
+// cliHistory shells out to git.
+type cliHistory struct {
+	cfg *Config
+}
+
+func (h *cliHistory) name() string { return gitBackendCLI }
+
+func (h *cliHistory) changedFiles(ctx context.Context) (map[string]bool, bool, error) {
+	return gitChangedFiles(ctx, h.cfg)
+}
+
+func (h *cliHistory) log(ctx context.Context, window churnWindow, mode logMode) ([]numstatCommit, bool, error) {
+	if cache := h.cfg.hotspotCache(); cache != nil {
+		return cachedCLILog(ctx, cache, window, mode)
+	}
+	if mode == logNameOnly {
+		return gitLogCommits(ctx, window, parseNameOnlyCommits, "--name-only")
+	}
+	return gitChurn(ctx, window)
+}
+
+// goGitHistory reads history in-process.
+type goGitHistory struct {
+	repo    *git.Repository
+	cfg     *Config
+	mailmap map[string]string
+}
+
+func openGoGitHistory(cfg *Config) (*goGitHistory, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, err
+	}
+	return &goGitHistory{repo: repo, cfg: cfg, mailmap: loadMailmapEmails(".mailmap")}, nil
+}
+
+var mailmapEmailPattern = regexp.MustCompile(`<([^>]*)>`)
+
+// loadMailmapEmails reads the email mappings of a .mailmap file so authors
+// match the CLI's %aE. Name-only entries do not affect emails and are ignored.
+func loadMailmapEmails(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	out := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		emails := mailmapEmailPattern.FindAllStringSubmatch(line, -1)
+		if len(emails) == 2 && emails[0][1] != "" {
+			out[strings.ToLower(emails[1][1])] = strings.ToLower(emails[0][1])
+		}
+	}
+	return out
+}
+
+func (h *goGitHistory) author(email string) string {
+	email = strings.ToLower(email)
+	if proper, ok := h.mailmap[email]; ok {
+		return proper
+	}
+	return email
+}
+
+func (h *goGitHistory) name() string { return gitBackendGoGit }
+
+func (h *goGitHistory) commit(rev string) (*object.Commit, error) {
+	hash, err := h.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, err
+	}
+	return h.repo.CommitObject(*hash)
+}
+
+// changedFiles mirrors gitChangedFiles: base...HEAD, then the last commit,
+// then nothing when the repository has no usable history.
+func (h *goGitHistory) changedFiles(ctx context.Context) (map[string]bool, bool, error) {
+	head, err := h.commit("HEAD")
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return map[string]bool{}, true, nil
+		}
+		return nil, false, err
+	}
+	base := ""
+	if h.cfg != nil {
+		base = strings.TrimSpace(h.cfg.BaseBranch)
+	}
+	var from *object.Commit
+	degraded := false
+	if base != "" {
+		if baseCommit, err := h.commit(base); err == nil {
+			if bases, err := baseCommit.MergeBase(head); err == nil && len(bases) > 0 {
+				from = bases[0]
+			}
+		}
+		if from == nil {
+			degraded = true
+			if h.cfg != nil && h.cfg.Verbose {
+				h.cfg.log().Infof("go-git could not resolve %s...HEAD; diffing the last commit", base)
+			}
+		}
+	}
+	if from == nil {
+		if head.NumParents() == 0 {
+			return map[string]bool{}, true, nil
+		}
+		if from, err = head.Parent(0); err != nil {
+			return map[string]bool{}, true, nil
+		}
+	}
+	changes, err := diffCommits(ctx, from, head)
+	if err != nil {
+		return nil, degraded, err
+	}
+	files := map[string]bool{}
+	for _, c := range changes {
+		files[changePath(c)] = true
+	}
+	return files, degraded, nil
+}
+
+func (h *goGitHistory) log(ctx context.Context, window churnWindow, mode logMode) ([]numstatCommit, bool, error) {
+	bounds, err := h.resolveWindow(window)
+	if err != nil {
+		return nil, false, err
+	}
+	start, err := h.commit(bounds.end)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			// Empty repository: no history, like isNoHistory for the CLI.
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+	missing, err := h.shallowParents()
+	if err != nil {
+		return nil, false, err
+	}
+	exclude, err := h.ancestors(bounds.start, missing)
+	if err != nil {
+		return nil, false, err
+	}
+	cache := h.cfg.hotspotCache()
+	var commits []numstatCommit
+	iter := object.NewCommitIterCTime(start, exclude, nil)
+	err = iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		when := c.Committer.When
+		if bounds.until != nil && when.After(*bounds.until) {
+			return nil
+		}
+		if bounds.since != nil && when.Before(*bounds.since) {
+			// Commits arrive newest first by committer time.
+			return storer.ErrStop
+		}
+		entry := numstatCommit{SHA: c.Hash.String(), Time: when, Author: h.author(c.Author.Email)}
+		// git log shows no diff for merge commits by default.
+		if c.NumParents() <= 1 {
+			files, ok := cache.commitFiles(entry.SHA, mode)
+			if !ok {
+				computed, err := h.cachedCommitFiles(ctx, c, entry, mode, missing, cache)
+				if err != nil {
+					return err
+				}
+				files = computed
+			}
+			entry.Files = files
+		}
+		commits = append(commits, entry)
+		if window.MaxCommits > 0 && len(commits) >= window.MaxCommits {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	cache.noteHead(commits)
+	if mode == logNumstat {
+		commits = foldRenames(commits)
+	}
+	return commits, false, nil
+}
+
+// cachedCommitFiles diffs a commit the cache does not know. With a cache, the
+// full numstat is computed and stored even for name-only reads. Shallow
+// boundary commits are left out: their empty-tree diff changes once the clone
+// is deepened.
+func (h *goGitHistory) cachedCommitFiles(ctx context.Context, c *object.Commit, entry numstatCommit, mode logMode, missing map[plumbing.Hash]bool, cache *hotspotCache) ([]numstatFile, error) {
+	if cache == nil {
+		return h.commitFiles(ctx, c, mode, missing)
+	}
+	files, err := h.commitFiles(ctx, c, logNumstat, missing)
+	if err != nil {
+		return nil, err
+	}
+	if c.NumParents() == 0 || !missing[c.ParentHashes[0]] {
+		entry.Files = files
+		cache.storeCommit(entry)
+	}
+	if mode == logNameOnly {
+		for i := range files {
+			files[i] = numstatFile{Path: files[i].Path}
+		}
+	}
+	return files, nil
+}
+
+// shallowParents lists the parents of shallow-clone boundary commits, which
+// are missing from the object store.
+func (h *goGitHistory) shallowParents() (map[plumbing.Hash]bool, error) {
+	missing := map[plumbing.Hash]bool{}
+	shallow, err := h.repo.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range shallow {
+		if c, err := h.repo.CommitObject(hash); err == nil {
+			for _, p := range c.ParentHashes {
+				missing[p] = true
+			}
+		}
+	}
+	return missing, nil
+}
+
+// ancestors returns the commits the walk must not enter: missing commits
+// plus, for a start..end range, every ancestor of start.
+func (h *goGitHistory) ancestors(rangeStart string, missing map[plumbing.Hash]bool) (map[plumbing.Hash]bool, error) {
+	exclude := make(map[plumbing.Hash]bool, len(missing))
+	for hash := range missing {
+		exclude[hash] = true
+	}
+	if rangeStart == "" {
+		return exclude, nil
+	}
+	from, err := h.commit(rangeStart)
+	if err != nil {
+		return nil, err
+	}
+	err = object.NewCommitPreorderIter(from, missing, nil).ForEach(func(c *object.Commit) error {
+		exclude[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exclude, nil
+}
+
+// commitFiles diffs c against its parent, or the empty tree for root and
+// shallow-boundary commits, as git log does.
+func (h *goGitHistory) commitFiles(ctx context.Context, c *object.Commit, mode logMode, missing map[plumbing.Hash]bool) ([]numstatFile, error) {
+	var parent *object.Commit
+	if c.NumParents() == 1 && !missing[c.ParentHashes[0]] {
+		p, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		parent = p
+	}
+	changes, err := diffCommits(ctx, parent, c)
+	if err != nil {
+		return nil, err
+	}
+	if mode == logNameOnly {
+		files := make([]numstatFile, 0, len(changes))
+		for _, change := range changes {
+			files = append(files, numstatFile{Path: changePath(change)})
+		}
+		return files, nil
+	}
+	patch, err := changes.PatchContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var files []numstatFile
+	for _, fp := range patch.FilePatches() {
+		from, to := fp.Files()
+		entry := numstatFile{Binary: fp.IsBinary()}
+		switch {
+		case to != nil:
+			entry.Path = to.Path()
+			if from != nil && from.Path() != to.Path() {
+				entry.OldPath = from.Path()
+			}
+		case from != nil:
+			entry.Path = from.Path()
+		default:
+			continue
+		}
+		if !entry.Binary {
+			for _, chunk := range fp.Chunks() {
+				switch chunk.Type() {
+				case diff.Add:
+					entry.Added += countLines(chunk.Content())
+				case diff.Delete:
+					entry.Deleted += countLines(chunk.Content())
+				}
+			}
+		}
+		files = append(files, entry)
+	}
+	return files, nil
+}
+
+// diffCommits compares from (nil for the empty tree) with to, detecting
+// renames like --find-renames.
+func diffCommits(ctx context.Context, from, to *object.Commit) (object.Changes, error) {
+	var fromTree *object.Tree
+	if from != nil {
+		t, err := from.Tree()
+		if err != nil {
+			return nil, err
+		}
+		fromTree = t
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+	opts := *object.DefaultDiffTreeOptions
+	opts.RenameScore = 50
+	return object.DiffTreeWithOptions(ctx, fromTree, toTree, &opts)
+}
+
+func changePath(c *object.Change) string {
+	if c.To.Name != "" {
+		return c.To.Name
+	}
+	return c.From.Name
+}
+
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	n := strings.Count(s, "\n")
+	if !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+type historyBounds struct {
+	since, until *time.Time
+	// start and end are revisions; the walk covers start..end.
+	start, end string
+}
+
+var relativeDatePattern = regexp.MustCompile(`^(\d+)[ .]*(second|minute|hour|day|week|month|year)s?([ .]+ago)?$`)
+
+// resolveWindow maps window bounds onto dates or revisions go-git can walk.
+// Bounds only git's approxidate parser understands return
+// errUnsupportedHistoryBound so the CLI can take over.
+func (h *goGitHistory) resolveWindow(w churnWindow) (historyBounds, error) {
+	b := historyBounds{end: "HEAD"}
+	resolve := func(value string) (*time.Time, string, error) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, "", nil
+		}
+		if t, ok := parseHistoryDate(value, time.Now()); ok {
+			return &t, "", nil
+		}
+		if _, err := h.repo.ResolveRevision(plumbing.Revision(value)); err == nil {
+			return nil, value, nil
+		}
+		return nil, "", fmt.Errorf("%w %q", errUnsupportedHistoryBound, value)
+	}
+	var err error
+	if b.since, b.start, err = resolve(w.Since); err != nil {
+		return b, err
+	}
+	var end string
+	if b.until, end, err = resolve(w.Until); err != nil {
+		return b, err
+	}
+	if end != "" {
+		b.end = end
+	}
+	return b, nil
+}
+
+// parseHistoryDate understands durations (14d, 336h), "<n> <unit>[s] [ago]"
+// and absolute dates.
+func parseHistoryDate(value string, now time.Time) (time.Time, bool) {
+	if d, ok := parseHistoryDuration(value); ok {
+		return now.Add(-d), true
+	}
+	if m := relativeDatePattern.FindStringSubmatch(strings.ToLower(value)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), true
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), true
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), true
+		case "day":
+			return now.AddDate(0, 0, -n), true
+		case "week":
+			return now.AddDate(0, 0, -7*n), true
+		case "month":
+			return now.AddDate(0, -n, 0), true
+		case "year":
+			return now.AddDate(-n, 0, 0), true
+		}
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
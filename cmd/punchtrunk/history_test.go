@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// historyFixture builds a repository with edits, a rename, a binary file, a
+// merge and a feature branch, and chdirs into it.
+func historyFixture(t *testing.T) {
+	t.Helper()
+	repo := t.TempDir()
+	gitInit(t, repo)
+	body := "package demo\n\nfunc a() int { return 1 }\nfunc b() int { return 2 }\nfunc c() int { return 3 }\nfunc d() int { return 4 }\n"
+	writeFile(t, repo, "handler.go", body)
+	writeFile(t, repo, "README.md", "demo\n")
+	writeFile(t, repo, ".mailmap", "Other Dev <other@example.com> <old.other@example.com> # renamed\n")
+	gitAddCommit(t, repo, "initial")
+	writeFile(t, repo, "handler.go", body+"func e() int { return 5 }\n")
+	if err := os.WriteFile(filepath.Join(repo, "logo.bin"), []byte{0, 1, 2, 0, 3}, 0o644); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+	gitAddCommit(t, repo, "grow handler, add logo")
+	if err := os.MkdirAll(filepath.Join(repo, "api"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	runGit(t, repo, "mv", "handler.go", "api/routes.go")
+	gitAddCommit(t, repo, "move handler")
+	runGit(t, repo, "branch", "main")
+	runGit(t, repo, "checkout", "-q", "-b", "feature")
+	writeFile(t, repo, "api/routes.go", body+"func e() int { return 5 }\nfunc f() int { return 6 }\n")
+	writeFile(t, repo, "README.md", "demo\nfeature\n")
+	runGit(t, repo, "commit", "-qam", "feature work", "--author=Other Dev <Other@Example.com>")
+	writeFile(t, repo, "api/routes.go", body+"func e() int { return 5 }\nfunc f() int { return 6 }\nfunc g() int { return 7 }\n")
+	runGit(t, repo, "commit", "-qam", "more feature work", "--author=Other Dev <Old.Other@example.com>")
+	runGit(t, repo, "checkout", "-q", "main")
+	writeFile(t, repo, "NOTES.md", "notes\n")
+	gitAddCommit(t, repo, "notes on main")
+	runGit(t, repo, "merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+	oldCwd := mustChdir(t, repo)
+	t.Cleanup(func() {
+		_ = os.Chdir(oldCwd)
+	})
+}
+
+func commitSummary(commits []numstatCommit) ([]string, map[string]int, map[string][]string) {
+	var shas []string
+	byAuthor := map[string][]string{}
+	for _, c := range commits {
+		shas = append(shas, c.SHA)
+		byAuthor[c.Author] = append(byAuthor[c.Author], c.SHA)
+	}
+	return shas, churnTotals(commits), byAuthor
+}
+
+func TestGoGitHistoryMatchesCLI(t *testing.T) {
+	historyFixture(t)
+	cfg := &Config{BaseBranch: "HEAD~1"}
+	cfg.logger = newEventLogger(io.Discard, false)
+	inProcess, err := openGoGitHistory(cfg)
+	if err != nil {
+		t.Fatalf("openGoGitHistory: %v", err)
+	}
+	cli := &cliHistory{cfg: cfg}
+	ctx := context.Background()
+
+	for _, window := range []churnWindow{
+		{Since: defaultHotspotSince},
+		{Since: "14d", MaxCommits: 3},
+		{Since: "HEAD~2"},
+	} {
+		for _, mode := range []logMode{logNumstat, logNameOnly} {
+			want, _, err := cli.log(ctx, window, mode)
+			if err != nil {
+				t.Fatalf("cli log %+v: %v", window, err)
+			}
+			got, degraded, err := inProcess.log(ctx, window, mode)
+			if err != nil || degraded {
+				t.Fatalf("go-git log %+v: degraded=%v err=%v", window, degraded, err)
+			}
+			wantSHAs, wantChurn, wantAuthors := commitSummary(want)
+			gotSHAs, gotChurn, gotAuthors := commitSummary(got)
+			if !reflect.DeepEqual(gotSHAs, wantSHAs) || !reflect.DeepEqual(gotAuthors, wantAuthors) {
+				t.Fatalf("window %+v mode %d: commits differ\ngo-git %v %v\ncli    %v %v", window, mode, gotSHAs, gotAuthors, wantSHAs, wantAuthors)
+			}
+			if mode == logNumstat && !reflect.DeepEqual(gotChurn, wantChurn) {
+				t.Fatalf("window %+v: churn differs\ngo-git %v\ncli    %v", window, gotChurn, wantChurn)
+			}
+			if mode == logNumstat && !reflect.DeepEqual(binaryPaths(got), binaryPaths(want)) {
+				t.Fatalf("window %+v: binary paths differ: %v vs %v", window, binaryPaths(got), binaryPaths(want))
+			}
+		}
+	}
+
+	for _, base := range []string{"HEAD~1", "feature", "no-such-branch"} {
+		cfg.BaseBranch = base
+		want, wantDegraded, err := cli.changedFiles(ctx)
+		if err != nil {
+			t.Fatalf("cli changedFiles %s: %v", base, err)
+		}
+		got, gotDegraded, err := inProcess.changedFiles(ctx)
+		if err != nil {
+			t.Fatalf("go-git changedFiles %s: %v", base, err)
+		}
+		if !reflect.DeepEqual(got, want) || gotDegraded != wantDegraded {
+			t.Fatalf("base %s: go-git %v (degraded %v), cli %v (degraded %v)", base, got, gotDegraded, want, wantDegraded)
+		}
+	}
+}
+
+func TestGoGitHistoryUnsupportedBoundFallsBack(t *testing.T) {
+	historyFixture(t)
+	cfg := &Config{}
+	cfg.logger = newEventLogger(io.Discard, false)
+	inProcess, err := openGoGitHistory(cfg)
+	if err != nil {
+		t.Fatalf("openGoGitHistory: %v", err)
+	}
+	window := churnWindow{Since: "last monday"}
+	if _, _, err := inProcess.log(context.Background(), window, logNumstat); !errors.Is(err, errUnsupportedHistoryBound) {
+		t.Fatalf("expected unsupported bound, got %v", err)
+	}
+	source, err := cfg.history()
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if _, ok := source.(*fallbackHistory); !ok {
+		t.Fatalf("expected auto backend to wrap go-git with the CLI, got %T", source)
+	}
+	if _, _, err := source.log(context.Background(), window, logNumstat); err != nil {
+		t.Fatalf("expected CLI fallback to accept approxidate, got %v", err)
+	}
+}
+
+func TestConfigHistoryBackends(t *testing.T) {
+	historyFixture(t)
+	if _, err := (&Config{GitBackend: "libgit2"}).history(); err == nil {
+		t.Fatalf("expected unknown backend to be rejected")
+	}
+	if source, err := (&Config{GitBackend: gitBackendCLI}).history(); err != nil || source.name() != gitBackendCLI {
+		t.Fatalf("expected CLI backend, got %v, %v", source, err)
+	}
+	if source, err := (&Config{GitBackend: gitBackendGoGit}).history(); err != nil {
+		t.Fatalf("go-git backend: %v", err)
+	} else if _, ok := source.(*goGitHistory); !ok {
+		t.Fatalf("expected bare go-git backend, got %T", source)
+	}
+}
+
+func TestAnalyzeHotspotsWithoutGitBinary(t *testing.T) {
+	historyFixture(t)
+	t.Setenv("PATH", t.TempDir())
+	cfg := &Config{BaseBranch: "HEAD~1", Timeout: 10 * time.Second}
+	cfg.logger = newEventLogger(io.Discard, false)
+	source, err := cfg.history()
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if _, ok := source.(*goGitHistory); !ok {
+		t.Fatalf("expected go-git without a git binary, got %T", source)
+	}
+	report, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	files := map[string]bool{}
+	for _, h := range report.Hotspots {
+		files[h.File] = h.Changed
+	}
+	if _, ok := files["api/routes.go"]; !ok {
+		t.Fatalf("expected api/routes.go in hotspots, got %+v", report.Hotspots)
+	}
+	if !files["README.md"] {
+		t.Fatalf("expected README.md to be marked changed by the merge, got %+v", files)
+	}
+}
+
+func TestParseHistoryDate(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"90 days":              now.AddDate(0, 0, -90),
+		"2 weeks ago":          now.AddDate(0, 0, -14),
+		"3.months.ago":         now.AddDate(0, -3, 0),
+		"336h":                 now.Add(-336 * time.Hour),
+		"1 year":               now.AddDate(-1, 0, 0),
+		"2024-01-15":           time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local),
+		"2024-01-15T10:00:00Z": time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		got, ok := parseHistoryDate(in, now)
+		if !ok || !got.Equal(want) {
+			t.Errorf("parseHistoryDate(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"last monday", "HEAD~3", "yesterday"} {
+		if _, ok := parseHistoryDate(in, now); ok {
+			t.Errorf("expected %q to be left to git", in)
+		}
+	}
+}
+
+func TestGoGitHistoryShallowClone(t *testing.T) {
+	historyFixture(t)
+	origin, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, origin, "clone", "-q", "--depth=2", "--branch=main", "file://"+origin, clone)
+	mustChdir(t, clone)
+
+	cfg := &Config{}
+	cfg.logger = newEventLogger(io.Discard, false)
+	inProcess, err := openGoGitHistory(cfg)
+	if err != nil {
+		t.Fatalf("openGoGitHistory: %v", err)
+	}
+	window := churnWindow{Since: defaultHotspotSince}
+	want, _, err := (&cliHistory{cfg: cfg}).log(context.Background(), window, logNumstat)
+	if err != nil {
+		t.Fatalf("cli log: %v", err)
+	}
+	got, _, err := inProcess.log(context.Background(), window, logNumstat)
+	if err != nil {
+		t.Fatalf("go-git log on shallow clone: %v", err)
+	}
+	wantSHAs, wantChurn, _ := commitSummary(want)
+	gotSHAs, gotChurn, _ := commitSummary(got)
+	if !reflect.DeepEqual(gotSHAs, wantSHAs) || !reflect.DeepEqual(gotChurn, wantChurn) {
+		t.Fatalf("shallow clone differs\ngo-git %v %v\ncli    %v %v", gotSHAs, gotChurn, wantSHAs, wantChurn)
+	}
+}
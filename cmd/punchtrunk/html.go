@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HTML hotspot report.
+//
+// --hotspot-html writes a single self-contained page: a sortable table, a
+// churn-versus-complexity scatter plot and per-directory rollups. Styles,
+// script and the SVG plot are inlined from an embedded template so the file
+// opens offline as a CI artifact on air-gapped runners; nothing is fetched
+// from a CDN.
+
+//go:embed templates/hotspots.html.tmpl
+var hotspotHTMLSource string
+
+var hotspotHTMLTemplate = template.Must(template.New("hotspots.html").Funcs(template.FuncMap{
+	"fixed": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+}).Parse(hotspotHTMLSource))
+
+// Scatter plot geometry in SVG user units.
+const (
+	scatterWidth   = 720
+	scatterHeight  = 420
+	scatterPadding = 48
+)
+
+type scatterPoint struct {
+	X, Y, R float64
+	Changed bool
+	Label   string
+}
+
+type scatterTick struct {
+	Pos   float64
+	Label string
+}
+
+type scatterPlot struct {
+	Width, Height   int
+	Left, Right     float64
+	Top, Bottom     float64
+	Points          []scatterPoint
+	XTicks, YTicks  []scatterTick
+	MaxChurn        int
+	MaxComplexity   float64
+	ChangedHotspots int
+}
+
+type hotspotHTMLView struct {
+	GeneratedAt string
+	Version     string
+	Window      string
+	Model       string
+	Complexity  string
+	Codeowners  string
+	Trend       *trendSummary
+	Selection   string
+	Touched     string
+	Hotspots    []Hotspot
+	Directories []hotspotRollup
+	Scatter     scatterPlot
+}
+
+func buildHotspotHTML(report *hotspotReport) hotspotHTMLView {
+	view := hotspotHTMLView{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Version:     Version,
+		Complexity:  report.ComplexityMode,
+		Codeowners:  report.Codeowners,
+		Trend:       report.Trend,
+		Hotspots:    report.Hotspots,
+		Directories: report.Rollups[rollupDirectory],
+		Scatter:     buildScatterPlot(report.Hotspots),
+	}
+	if report.Window.Since != "" {
+		view.Window = report.Window.describe()
+	}
+	if report.Model.Name != "" {
+		view.Model = report.Model.describe()
+	}
+	if report.Selection.changedOnly() {
+		view.Touched = report.Selection.touchedSummary()
+	}
+	if sel := report.Selection; sel != nil && sel.droppedTotal() > 0 {
+		view.Selection = fmt.Sprintf("%d of %d ranked files not reported: %s", sel.droppedTotal(), sel.Ranked, sel.describeDropped())
+	}
+	return view
+}
+
+// buildScatterPlot lays hotspots out with churn on a log axis (churn is
+// heavily skewed) and complexity on a linear axis. Point area follows score.
+func buildScatterPlot(hs []Hotspot) scatterPlot {
+	plot := scatterPlot{
+		Width:  scatterWidth,
+		Height: scatterHeight,
+		Left:   scatterPadding,
+		Right:  scatterWidth - scatterPadding/2,
+		Top:    scatterPadding / 2,
+		Bottom: scatterHeight - scatterPadding,
+	}
+	maxScore := 0.0
+	for _, h := range hs {
+		if h.Churn > plot.MaxChurn {
+			plot.MaxChurn = h.Churn
+		}
+		if h.Complexity > plot.MaxComplexity {
+			plot.MaxComplexity = h.Complexity
+		}
+		if h.Score > maxScore {
+			maxScore = h.Score
+		}
+		if h.Changed {
+			plot.ChangedHotspots++
+		}
+	}
+	xMax := math.Log10(float64(plot.MaxChurn) + 1)
+	if xMax == 0 {
+		xMax = 1
+	}
+	yMax := plot.MaxComplexity
+	if yMax <= 0 {
+		yMax = 1
+	}
+	x := func(churn float64) float64 {
+		return plot.Left + (plot.Right-plot.Left)*math.Log10(churn+1)/xMax
+	}
+	y := func(complexity float64) float64 {
+		return plot.Bottom - (plot.Bottom-plot.Top)*complexity/yMax
+	}
+	for _, h := range hs {
+		r := 3.0
+		if maxScore > 0 && h.Score > 0 {
+			r += 7 * math.Sqrt(h.Score/maxScore)
+		}
+		plot.Points = append(plot.Points, scatterPoint{
+			X:       math.Round(x(float64(h.Churn))*10) / 10,
+			Y:       math.Round(y(math.Max(h.Complexity, 0))*10) / 10,
+			R:       math.Round(r*10) / 10,
+			Changed: h.Changed,
+			Label:   fmt.Sprintf("#%d %s: churn %d, complexity %.2f, score %.2f", h.Rank, filepath.ToSlash(h.File), h.Churn, h.Complexity, h.Score),
+		})
+	}
+	for churn := 1; churn <= plot.MaxChurn; churn *= 10 {
+		plot.XTicks = append(plot.XTicks, scatterTick{Pos: x(float64(churn)), Label: fmt.Sprint(churn)})
+	}
+	for i := 0; i <= 4; i++ {
+		v := yMax * float64(i) / 4
+		plot.YTicks = append(plot.YTicks, scatterTick{Pos: y(v), Label: fmt.Sprintf("%.1f", v)})
+	}
+	return plot
+}
+
+func writeHotspotHTML(path string, report *hotspotReport) error {
+	var buf bytes.Buffer
+	if err := hotspotHTMLTemplate.Execute(&buf, buildHotspotHTML(report)); err != nil {
+		return fmt.Errorf("render hotspot HTML: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
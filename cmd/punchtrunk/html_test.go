@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteHotspotHTMLIsSelfContained(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hotspots.html")
+	hs := []Hotspot{
+		{File: "pkg/api/handler.go", Churn: 120, Complexity: 4.5, Score: 3.2, Rank: 1, Percentile: 50, Changed: true,
+			Ownership: &fileOwnership{Authors: 2, BusFactor: 1, Owners: []string{"@team/api"}}},
+		{File: "docs/<script>.md", Churn: 3, Complexity: 0.5, Score: -1.1, Rank: 2},
+	}
+	report := &hotspotReport{
+		Hotspots: hs,
+		Window:   churnWindow{Since: "90 days ago"},
+		Rollups:  computeRollups(hs, 2, nil),
+		Trend:    &trendSummary{Baseline: "main.sarif", New: 1},
+	}
+	if err := writeHotspotHTML(path, report); err != nil {
+		t.Fatalf("writeHotspotHTML: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read HTML: %v", err)
+	}
+	html := string(data)
+	for _, want := range []string{
+		`<table class="sortable" id="hotspots">`,
+		`<table class="sortable" id="directories">`,
+		`<td class="file">pkg/api</td>`,
+		"<svg viewBox=",
+		`class="changed"><title>#1 pkg/api/handler.go`,
+		"@team/api",
+		"vs <code>main.sarif</code>: 1 new",
+		"docs/&lt;script&gt;.md",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected HTML to contain %q", want)
+		}
+	}
+	for _, external := range []string{"http://", "https://", "<link", "src="} {
+		if strings.Contains(html, external) {
+			t.Errorf("expected no external assets, found %q", external)
+		}
+	}
+	if got := strings.Count(html, "<circle "); got != len(hs) {
+		t.Fatalf("expected %d scatter points, got %d", len(hs), got)
+	}
+}
+
+func TestBuildScatterPlotStaysInBounds(t *testing.T) {
+	plot := buildScatterPlot([]Hotspot{
+		{File: "a.go", Churn: 0, Complexity: 0},
+		{File: "b.go", Churn: 5000, Complexity: 12, Score: 9},
+		{File: "c.go", Churn: 40, Complexity: -2, Score: -3},
+	})
+	for _, p := range plot.Points {
+		if p.X < plot.Left || p.X > plot.Right || p.Y < plot.Top || p.Y > plot.Bottom {
+			t.Fatalf("point %+v outside plot area %+v", p, plot)
+		}
+	}
+	if len(plot.XTicks) != 4 {
+		t.Fatalf("expected ticks at 1, 10, 100, 1000; got %+v", plot.XTicks)
+	}
+	if empty := buildScatterPlot(nil); len(empty.Points) != 0 || len(empty.YTicks) == 0 {
+		t.Fatalf("expected an empty plot with axes, got %+v", empty)
+	}
+}
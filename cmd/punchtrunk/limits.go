@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Hotspot selection.
+//
+// Every file is ranked first, so rank, percentile and rollups always describe
+// the whole repository. Reported results are then narrowed:
+// --hotspot-scope=changed keeps only files in the base...HEAD diff, files
+// below --hotspot-min-churn or --hotspot-min-score are dropped, and the
+// remainder is cut to --hotspot-limit (default 500; 0 also selects the
+// default, negative values are rejected). The selection, including how many
+// files each step dropped, is recorded in every report so a short list is
+// never mistaken for a quiet repository.
+
+const (
+	defaultHotspotLimit = 500
+
+	hotspotScopeRepo    = "repo"
+	hotspotScopeChanged = "changed"
+	// scopeTopN is the "you touched N of the top 10" window for PR summaries.
+	scopeTopN = 10
+
+	dropScope    = "scope"
+	dropMinChurn = "min_churn"
+	dropMinScore = "min_score"
+	dropLimit    = "limit"
+)
+
+type hotspotSelection struct {
+	Scope     string         `json:"scope"`
+	Limit     int            `json:"limit"`
+	MinScore  *float64       `json:"min_score,omitempty"`
+	MinChurn  int            `json:"min_churn,omitempty"`
+	Ranked    int            `json:"ranked"`
+	Reported  int            `json:"reported"`
+	Truncated bool           `json:"truncated"`
+	Dropped   map[string]int `json:"dropped,omitempty"`
+	// ChangedRanked and ChangedInTop count changed files in the full ranking
+	// and among its top scopeTopN.
+	ChangedRanked int `json:"changed_ranked"`
+	ChangedInTop  int `json:"changed_in_top_10"`
+}
+
+// hotspotSelection validates the selection flags.
+func (cfg *Config) hotspotSelection() (*hotspotSelection, error) {
+	sel := &hotspotSelection{Scope: hotspotScopeRepo, Limit: defaultHotspotLimit}
+	if cfg == nil {
+		return sel, nil
+	}
+	switch scope := strings.ToLower(strings.TrimSpace(cfg.HotspotScope)); scope {
+	case "", hotspotScopeRepo:
+	case hotspotScopeChanged:
+		sel.Scope = scope
+	default:
+		return nil, fmt.Errorf("unsupported hotspot-scope %q (expected repo or changed)", cfg.HotspotScope)
+	}
+	// 0 is the zero value of an unset Config and keeps the default.
+	switch {
+	case cfg.HotspotLimit < 0:
+		return nil, fmt.Errorf("invalid hotspot-limit %d: must be positive", cfg.HotspotLimit)
+	case cfg.HotspotLimit > 0:
+		sel.Limit = cfg.HotspotLimit
+	}
+	if cfg.HotspotMinChurn < 0 {
+		return nil, fmt.Errorf("invalid hotspot-min-churn %d: must not be negative", cfg.HotspotMinChurn)
+	}
+	sel.MinChurn = cfg.HotspotMinChurn
+	if raw := strings.TrimSpace(cfg.HotspotMinScore); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid hotspot-min-score %q: expected a number", raw)
+		}
+		sel.MinScore = &v
+	}
+	return sel, nil
+}
+
+func (s *hotspotSelection) describe() string {
+	parts := []string{fmt.Sprintf("top %d", s.Limit)}
+	if s.changedOnly() {
+		parts = append(parts, "changed files only")
+	}
+	if s.MinScore != nil {
+		parts = append(parts, "score>="+s.minScoreLabel())
+	}
+	if s.MinChurn > 0 {
+		parts = append(parts, fmt.Sprintf("churn>=%d", s.MinChurn))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s *hotspotSelection) changedOnly() bool {
+	return s != nil && s.Scope == hotspotScopeChanged
+}
+
+// touchedSummary phrases the changed-file count for PR comments.
+func (s *hotspotSelection) touchedSummary() string {
+	return fmt.Sprintf("You touched %d of the top %d hottest files (%d changed files ranked of %d).",
+		s.ChangedInTop, scopeTopN, s.ChangedRanked, s.Ranked)
+}
+
+func (s *hotspotSelection) minScoreLabel() string {
+	if s.MinScore == nil {
+		return "minimum"
+	}
+	return strconv.FormatFloat(*s.MinScore, 'g', -1, 64)
+}
+
+// apply narrows ranked hotspots (sorted, best first) to the reported set and
+// records what was dropped.
+func (s *hotspotSelection) apply(hs []Hotspot) []Hotspot {
+	s.Ranked = len(hs)
+	s.Dropped = map[string]int{}
+	kept := make([]Hotspot, 0, len(hs))
+	for _, h := range hs {
+		if h.Changed {
+			s.ChangedRanked++
+			if h.Rank > 0 && h.Rank <= scopeTopN {
+				s.ChangedInTop++
+			}
+		}
+		switch {
+		case s.changedOnly() && !h.Changed:
+			s.Dropped[dropScope]++
+		case h.Churn < s.MinChurn:
+			s.Dropped[dropMinChurn]++
+		case s.MinScore != nil && h.Score < *s.MinScore:
+			s.Dropped[dropMinScore]++
+		default:
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) > s.Limit {
+		s.Dropped[dropLimit] = len(kept) - s.Limit
+		s.Truncated = true
+		kept = kept[:s.Limit]
+	}
+	for reason, n := range s.Dropped {
+		if n == 0 {
+			delete(s.Dropped, reason)
+		}
+	}
+	s.Reported = len(kept)
+	return kept
+}
+
+func (s *hotspotSelection) droppedTotal() int {
+	total := 0
+	for _, n := range s.Dropped {
+		total += n
+	}
+	return total
+}
+
+// describeDropped lists drop counts in the order the steps run.
+func (s *hotspotSelection) describeDropped() string {
+	var parts []string
+	for _, step := range []struct{ reason, label string }{
+		{dropScope, "outside the change"},
+		{dropMinChurn, fmt.Sprintf("churn below %d", s.MinChurn)},
+		{dropMinScore, "score below " + s.minScoreLabel()},
+		{dropLimit, fmt.Sprintf("past the top %d", s.Limit)},
+	} {
+		if n := s.Dropped[step.reason]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, step.label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s *hotspotSelection) logFields() LogFields {
+	fields := LogFields{
+		"ranked":    s.Ranked,
+		"reported":  s.Reported,
+		"limit":     s.Limit,
+		"truncated": s.Truncated,
+		"scope":     s.Scope,
+	}
+	for reason, n := range s.Dropped {
+		fields["dropped_"+reason] = n
+	}
+	return fields
+}
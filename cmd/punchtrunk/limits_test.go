@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHotspotSelectionValidation(t *testing.T) {
+	sel, err := (&Config{}).hotspotSelection()
+	if err != nil || sel.Limit != defaultHotspotLimit || sel.MinScore != nil || sel.MinChurn != 0 {
+		t.Fatalf("unexpected defaults: %+v, %v", sel, err)
+	}
+	sel, err = (&Config{HotspotLimit: 20, HotspotMinScore: "-0.5", HotspotMinChurn: 10}).hotspotSelection()
+	if err != nil || sel.Limit != 20 || sel.MinScore == nil || *sel.MinScore != -0.5 || sel.MinChurn != 10 {
+		t.Fatalf("unexpected selection: %+v, %v", sel, err)
+	}
+	for _, cfg := range []*Config{
+		{HotspotLimit: -1},
+		{HotspotMinChurn: -3},
+		{HotspotMinScore: "high"},
+		{HotspotMinScore: "NaN"},
+	} {
+		if _, err := cfg.hotspotSelection(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestHotspotSelectionApplyRecordsDrops(t *testing.T) {
+	minScore := 1.0
+	sel := &hotspotSelection{Limit: 2, MinScore: &minScore, MinChurn: 5}
+	hs := []Hotspot{
+		{File: "a.go", Churn: 50, Score: 4, Rank: 1},
+		{File: "b.go", Churn: 2, Score: 3, Rank: 2},
+		{File: "c.go", Churn: 40, Score: 2, Rank: 3},
+		{File: "d.go", Churn: 30, Score: 1.5, Rank: 4},
+		{File: "e.go", Churn: 20, Score: 0.5, Rank: 5},
+	}
+	got := sel.apply(hs)
+	var files []string
+	for _, h := range got {
+		files = append(files, h.File)
+	}
+	if !reflect.DeepEqual(files, []string{"a.go", "c.go"}) {
+		t.Fatalf("unexpected selection %v", files)
+	}
+	if got[1].Rank != 3 {
+		t.Fatalf("expected repo-wide rank to be kept, got %d", got[1].Rank)
+	}
+	want := map[string]int{dropMinChurn: 1, dropMinScore: 1, dropLimit: 1}
+	if !reflect.DeepEqual(sel.Dropped, want) || sel.Ranked != 5 || sel.Reported != 2 || !sel.Truncated {
+		t.Fatalf("unexpected bookkeeping: %+v", sel)
+	}
+	if desc := sel.describeDropped(); desc != "1 churn below 5, 1 score below 1, 1 past the top 2" {
+		t.Fatalf("unexpected description %q", desc)
+	}
+
+	untouched := &hotspotSelection{Limit: 10}
+	untouched.apply(hs)
+	if untouched.Truncated || len(untouched.Dropped) != 0 || untouched.Reported != len(hs) {
+		t.Fatalf("expected nothing dropped, got %+v", untouched)
+	}
+}
+
+func TestBuildHotspotSARIFRecordsSelection(t *testing.T) {
+	sel := &hotspotSelection{Limit: 1}
+	hs := sel.apply([]Hotspot{{File: "a.go", Score: 2}, {File: "b.go", Score: 1}})
+	log := buildHotspotSARIF(&hotspotReport{
+		Hotspots:  hs,
+		Window:    churnWindow{Since: "90 days ago"},
+		Selection: sel,
+	})
+	got, ok := log.Runs[0].Properties["selection"].(*hotspotSelection)
+	if !ok || !got.Truncated || got.Dropped[dropLimit] != 1 {
+		t.Fatalf("expected selection in run properties, got %#v", log.Runs[0].Properties["selection"])
+	}
+	if len(log.Runs[0].Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(log.Runs[0].Results))
+	}
+	doc := buildHotspotJSON(&hotspotReport{Hotspots: hs, Selection: sel})
+	if doc.Selection != sel {
+		t.Fatalf("expected selection in JSON report")
+	}
+	if !strings.Contains(buildHotspotHTML(&hotspotReport{Hotspots: hs, Selection: sel}).Selection, "1 of 2 ranked files not reported") {
+		t.Fatalf("expected selection note in HTML view")
+	}
+}
+
+func TestHotspotSelectionChangedScope(t *testing.T) {
+	if _, err := (&Config{HotspotScope: "branch"}).hotspotSelection(); err == nil {
+		t.Fatalf("expected unknown scope to be rejected")
+	}
+	sel, err := (&Config{HotspotScope: "Changed", HotspotLimit: 1}).hotspotSelection()
+	if err != nil || !sel.changedOnly() {
+		t.Fatalf("expected changed scope, got %+v, %v", sel, err)
+	}
+	var hs []Hotspot
+	for i := 1; i <= 12; i++ {
+		hs = append(hs, Hotspot{File: fmt.Sprintf("f%02d.go", i), Rank: i, Churn: 1, Changed: i == 2 || i == 7 || i == 12})
+	}
+	got := sel.apply(hs)
+	if len(got) != 1 || got[0].File != "f02.go" || got[0].Rank != 2 {
+		t.Fatalf("expected the top changed file with its repo-wide rank, got %+v", got)
+	}
+	want := map[string]int{dropScope: 9, dropLimit: 2}
+	if !reflect.DeepEqual(sel.Dropped, want) {
+		t.Fatalf("unexpected drops %v", sel.Dropped)
+	}
+	if sel.ChangedRanked != 3 || sel.ChangedInTop != 2 {
+		t.Fatalf("unexpected changed counts: %+v", sel)
+	}
+	if summary := sel.touchedSummary(); !strings.HasPrefix(summary, "You touched 2 of the top 10 hottest files") {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+}
+
+func TestAnalyzeHotspotsChangedScopeKeepsRepoRank(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "hot.go", "package main\n\nfunc hot() {}\n")
+	writeFile(t, repo, "cold.go", "package main\n\nfunc cold() {}\n")
+	gitAddCommit(t, repo, "initial")
+	for i := 0; i < 3; i++ {
+		writeFile(t, repo, "hot.go", fmt.Sprintf("package main\n\nfunc hot() {\n\tif true {\n\t\tprintln(%d)\n\t}\n}\n", i))
+		gitAddCommit(t, repo, fmt.Sprintf("hot %d", i))
+	}
+	runGit(t, repo, "branch", "base")
+	writeFile(t, repo, "cold.go", "package main\n\nfunc cold() { println() }\n")
+	gitAddCommit(t, repo, "touch cold")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{BaseBranch: "base", Timeout: 10 * time.Second, HotspotScope: hotspotScopeChanged}
+	cfg.logger = newEventLogger(io.Discard, false)
+	report, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	if len(report.Hotspots) != 1 || report.Hotspots[0].File != "cold.go" {
+		t.Fatalf("expected only the changed file, got %+v", report.Hotspots)
+	}
+	if report.Hotspots[0].Rank != 2 || report.Selection.Ranked != 2 {
+		t.Fatalf("expected repo-wide rank 2 of 2, got rank %d of %d", report.Hotspots[0].Rank, report.Selection.Ranked)
+	}
+	log := buildHotspotSARIF(report)
+	if msg := log.Runs[0].Results[0].Message.Text; !strings.Contains(msg, "repo-wide rank #2 of 2") {
+		t.Fatalf("expected repo-wide rank in message, got %q", msg)
+	}
+	if summary, _ := log.Runs[0].Properties["summary"].(string); !strings.HasPrefix(summary, "You touched 1 of the top 10") {
+		t.Fatalf("expected touched summary, got %q", summary)
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lint SARIF.
+//
+// With --lint-sarif-out, lint mode runs `trunk check --output=json`, parses
+// the issues and writes them as SARIF: one rule per linter and code, results
+// with regions and levels, and whether trunk offers an autofix. Trunk's
+// stderr still streams to the console and a one-line summary replaces the
+// human-readable table. Linter crashes reported by trunk are kept as tool
+// failures in the run properties. Parsed runs are judged by the lint gating
+// policy (lintgate.go), after any lint baseline (lintbaseline.go) drops
+// known issues; failures are deferred to exit (via exitErr) so later
+// modes and --sarif-merge still run.
+
+const (
+	lintInformationURI = "https://docs.trunk.io/check"
+	// trunkOutputJSON switches trunk check to machine-readable output.
+	trunkOutputJSON = "--output=json"
+)
+
+// trunkCheckJSON is the subset of `trunk check --output=json` PunchTrunk
+// reads.
+type trunkCheckJSON struct {
+	Issues   []trunkIssueJSON   `json:"issues"`
+	Failures []trunkFailureJSON `json:"failures"`
+}
+
+type trunkIssueJSON struct {
+	File           string            `json:"file"`
+	Line           flexInt           `json:"line"`
+	Column         flexInt           `json:"column"`
+	EndLine        flexInt           `json:"endLine"`
+	EndColumn      flexInt           `json:"endColumn"`
+	Message        string            `json:"message"`
+	Code           string            `json:"code"`
+	Level          string            `json:"level"`
+	Linter         string            `json:"linter"`
+	IssueURL       string            `json:"issueUrl"`
+	AutofixOptions []json.RawMessage `json:"autofixOptions"`
+}
+
+type trunkFailureJSON struct {
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+	DetailPath string `json:"detailPath"`
+}
+
+// flexInt accepts numbers and the quoted integers protobuf JSON emits.
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*n = flexInt(v)
+	return nil
+}
+
+type lintIssue struct {
+	File      string `json:"file"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	Linter    string `json:"linter"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+	// Level is the SARIF level: error, warning or note.
+	Level   string `json:"level"`
+	HelpURI string `json:"help_uri,omitempty"`
+	Autofix bool   `json:"autofix,omitempty"`
+	// Fingerprint is set when a lint baseline is in use (lintbaseline.go).
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ruleID names the SARIF rule of an issue: linter/code, or the linter alone.
+func (i lintIssue) ruleID() string {
+	if i.Code == "" {
+		return i.Linter
+	}
+	return i.Linter + "/" + i.Code
+}
+
+type lintFailure struct {
+	Linter     string `json:"linter"`
+	Message    string `json:"message,omitempty"`
+	DetailPath string `json:"detail_path,omitempty"`
+}
+
+type lintReport struct {
+	Issues   []lintIssue
+	Failures []lintFailure
+	// Baselined and Fixed count issues matched by, and missing from, the
+	// lint baseline; Issues then holds only new ones.
+	Baselined int
+	Fixed     int
+	baseline  bool
+}
+
+// lintCapture reports whether lint mode must parse trunk's issues.
+func (cfg *Config) lintCapture() bool {
+	if cfg == nil {
+		return false
+	}
+	if strings.TrimSpace(cfg.LintSarifOut) != "" || cfg.SarifMerge != "" || strings.TrimSpace(cfg.LintBaseline) != "" {
+		return true
+	}
+	policy, err := cfg.lintPolicy()
+	return err == nil && policy.active()
+}
+
+// parseTrunkCheckJSON reads trunk check JSON output. Anything printed before
+// the document (update notices, progress) is skipped.
+func parseTrunkCheckJSON(output []byte) (*lintReport, error) {
+	start := bytes.IndexByte(output, '{')
+	if start < 0 {
+		return nil, errors.New("no JSON document in trunk check output")
+	}
+	var doc trunkCheckJSON
+	if err := json.NewDecoder(bytes.NewReader(output[start:])).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse trunk check output: %w", err)
+	}
+	report := &lintReport{}
+	for _, raw := range doc.Issues {
+		issue := lintIssue{
+			File:      filepath.ToSlash(strings.TrimPrefix(raw.File, "./")),
+			Line:      int(raw.Line),
+			Column:    int(raw.Column),
+			EndLine:   int(raw.EndLine),
+			EndColumn: int(raw.EndColumn),
+			Linter:    strings.TrimSpace(raw.Linter),
+			Code:      strings.TrimSpace(raw.Code),
+			Message:   strings.TrimSpace(raw.Message),
+			Level:     lintLevel(raw.Level),
+			HelpURI:   raw.IssueURL,
+			Autofix:   len(raw.AutofixOptions) > 0,
+		}
+		if issue.Linter == "" {
+			issue.Linter = "trunk"
+		}
+		if issue.Message == "" {
+			issue.Message = issue.ruleID()
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	for _, f := range doc.Failures {
+		report.Failures = append(report.Failures, lintFailure{Linter: f.Name, Message: strings.TrimSpace(f.Message), DetailPath: f.DetailPath})
+	}
+	sortLintIssues(report.Issues)
+	return report, nil
+}
+
+func sortLintIssues(issues []lintIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.ruleID() < b.ruleID()
+	})
+}
+
+// lintLevel maps trunk levels (LEVEL_HIGH, LEVEL_MEDIUM, LEVEL_LOW) to SARIF.
+func lintLevel(level string) string {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(level)), "level_") {
+	case "high", "error":
+		return "error"
+	case "low", "note", "info":
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+// runTrunkCheckCaptured runs trunk check with JSON output, once per argument
+// batch (see targets.go), and writes the lint SARIF. trunk's first failing
+// exit status is returned alongside the merged report; a nil report means
+// trunk or the SARIF write failed outright.
+func runTrunkCheckCaptured(ctx context.Context, cfg *Config, batches [][]string) (*lintReport, error) {
+	report := &lintReport{}
+	var runErr error
+	for _, args := range batches {
+		if cfg.Verbose {
+			cfg.log().Infof("Running: %s %s", cfg.trunkBinary(), strings.Join(args, " "))
+		}
+		part, err := captureTrunkCheck(ctx, cfg, args)
+		if part == nil {
+			return nil, err
+		}
+		report.Issues = append(report.Issues, part.Issues...)
+		report.Failures = append(report.Failures, part.Failures...)
+		if runErr == nil {
+			runErr = err
+		}
+	}
+	sortLintIssues(report.Issues)
+	if err := applyLintBaseline(cfg, report); err != nil {
+		return nil, err
+	}
+	fmt.Println(report.summary())
+	for _, f := range report.Failures {
+		cfg.log().Warnf("trunk check: %s failed: %s", f.Linter, f.Message)
+	}
+	if strings.TrimSpace(cfg.LintSarifOut) != "" {
+		if err := writeLintSARIF(cfg, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, runErr
+}
+
+// captureTrunkCheck runs one trunk check invocation and parses its output.
+func captureTrunkCheck(ctx context.Context, cfg *Config, args []string) (*lintReport, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.trunkBinary(), args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	applyTrunkCommandEnv(cmd, cfg)
+	runErr := cmd.Run()
+	report, parseErr := parseTrunkCheckJSON(stdout.Bytes())
+	if parseErr != nil {
+		if runErr != nil {
+			// trunk failed before producing a report; surface its error.
+			cfg.log().Warnf("lint SARIF not written: %v", parseErr)
+			os.Stdout.Write(stdout.Bytes())
+			return nil, runErr
+		}
+		return nil, parseErr
+	}
+	return report, runErr
+}
+
+func (r *lintReport) summary() string {
+	linters := map[string]bool{}
+	for _, i := range r.Issues {
+		linters[i.Linter] = true
+	}
+	msg := fmt.Sprintf("trunk check: %d issues from %d linters", len(r.Issues), len(linters))
+	if len(r.Failures) > 0 {
+		msg += fmt.Sprintf(", %d linter failures", len(r.Failures))
+	}
+	if r.baseline {
+		msg += fmt.Sprintf(" (new since baseline; %d baselined, %d fixed)", r.Baselined, r.Fixed)
+	}
+	return msg
+}
+
+func writeLintSARIF(cfg *Config, report *lintReport) error {
+	out, err := prepareReportPath(cfg, cfg.LintSarifOut)
+	if err != nil {
+		return err
+	}
+	cfg.LintSarifOut = out
+	log := SarifLog{
+		Version: "2.1.0",
+		Schema:  sarifSchemaURI,
+		Runs:    []SarifRun{lintRun("trunk", cfg.TrunkVersion, report.Issues, report.Failures)},
+	}
+	if err := writeIndentedJSON(out, &log); err != nil {
+		return fmt.Errorf("write lint SARIF: %w", err)
+	}
+	cfg.log().Event("info", "lint.sarif.write", LogFields{
+		"sarif_out": out,
+		"count":     len(report.Issues),
+		"failures":  len(report.Failures),
+	})
+	return nil
+}
+
+// lintRun builds one SARIF run for issues with a rule per linter/code, in
+// first-seen order.
+func lintRun(name, version string, issues []lintIssue, failures []lintFailure) SarifRun {
+	run := SarifRun{
+		Tool: SarifTool{Driver: SarifDriver{
+			Name:           name,
+			Version:        version,
+			InformationURI: lintInformationURI,
+		}},
+		Results: []SarifResult{},
+	}
+	ruleIndex := map[string]int{}
+	for _, issue := range issues {
+		id := issue.ruleID()
+		idx, ok := ruleIndex[id]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[id] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, lintRule(issue))
+		}
+		run.Results = append(run.Results, lintResult(issue, idx))
+	}
+	if len(failures) > 0 {
+		run.Properties = map[string]any{"tool_failures": failures}
+	}
+	return run
+}
+
+func lintRule(issue lintIssue) SarifReportingDescriptor {
+	desc := fmt.Sprintf("%s reported %s", issue.Linter, issue.Code)
+	if issue.Code == "" {
+		desc = fmt.Sprintf("Issue reported by %s", issue.Linter)
+	}
+	return SarifReportingDescriptor{
+		ID:                   issue.ruleID(),
+		Name:                 issue.ruleID(),
+		ShortDescription:     &SarifMessage{Text: desc},
+		HelpURI:              issue.HelpURI,
+		DefaultConfiguration: &SarifRuleConfiguration{Level: issue.Level},
+		Properties: map[string]any{
+			"tags":   []string{"lint", issue.Linter},
+			"linter": issue.Linter,
+		},
+	}
+}
+
+func lintResult(issue lintIssue, ruleIndex int) SarifResult {
+	result := SarifResult{
+		RuleID:    issue.ruleID(),
+		RuleIndex: &ruleIndex,
+		Level:     issue.Level,
+		Message:   SarifMessage{Text: issue.Message},
+		Locations: []SarifLocation{{
+			PhysicalLocation: SarifPhysicalLocation{
+				ArtifactLocation: SarifArtifactLocation{URI: issue.File},
+				Region:           issue.region(),
+			},
+		}},
+		Properties: map[string]any{
+			"linter":            issue.Linter,
+			"autofix_available": issue.Autofix,
+		},
+	}
+	if issue.Code != "" {
+		result.Properties["code"] = issue.Code
+	}
+	if issue.Fingerprint != "" {
+		result.PartialFingerprints = map[string]string{lintFingerprintKey: issue.Fingerprint}
+	}
+	return result
+}
+
+// region returns the issue's SARIF region, or nil for file-level issues.
+func (i lintIssue) region() *SarifRegion {
+	if i.Line <= 0 {
+		return nil
+	}
+	r := &SarifRegion{StartLine: i.Line, StartColumn: i.Column}
+	if i.EndLine >= i.Line {
+		r.EndLine = i.EndLine
+		r.EndColumn = i.EndColumn
+	}
+	return r
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+const trunkCheckFixture = `Checking 3 files
+{
+  "issues": [
+    {"file": "src/app.ts", "line": "12", "column": "5", "endLine": 12, "endColumn": 9, "message": "'x' is unused", "code": "no-unused-vars", "level": "LEVEL_HIGH", "linter": "eslint", "issueUrl": "https://eslint.org/docs/rules/no-unused-vars", "autofixOptions": [{"replacements": []}]},
+    {"file": "./src/app.ts", "line": 3, "column": 1, "message": "prefer const", "code": "prefer-const", "level": "LEVEL_MEDIUM", "linter": "eslint"},
+    {"file": "README.md", "line": 40, "message": "Line length", "code": "MD013", "level": "LEVEL_LOW", "linter": "markdownlint"},
+    {"file": "src/other.ts", "line": 7, "message": "'y' is unused", "code": "no-unused-vars", "level": "LEVEL_HIGH", "linter": "eslint"},
+    {"file": "go.mod", "message": "module needs tidy", "linter": "gomod"}
+  ],
+  "failures": [
+    {"name": "shellcheck", "message": "exit status 2", "detailPath": "/tmp/trunk/shellcheck.log"}
+  ]
+}
+`
+
+func TestParseTrunkCheckJSON(t *testing.T) {
+	report, err := parseTrunkCheckJSON([]byte(trunkCheckFixture))
+	if err != nil {
+		t.Fatalf("parseTrunkCheckJSON: %v", err)
+	}
+	if len(report.Issues) != 5 || len(report.Failures) != 1 {
+		t.Fatalf("expected 5 issues and 1 failure, got %d and %d", len(report.Issues), len(report.Failures))
+	}
+	first := report.Issues[0]
+	if first.File != "README.md" || first.Level != "note" || first.ruleID() != "markdownlint/MD013" {
+		t.Fatalf("unexpected first issue %+v", first)
+	}
+	var unused lintIssue
+	for _, i := range report.Issues {
+		if i.File == "src/app.ts" && i.Line == 12 {
+			unused = i
+		}
+	}
+	if unused.Column != 5 || unused.EndColumn != 9 || unused.Level != "error" || !unused.Autofix {
+		t.Fatalf("unexpected parsed issue %+v", unused)
+	}
+	if _, err := parseTrunkCheckJSON([]byte("trunk crashed\n")); err == nil {
+		t.Fatalf("expected an error for output without JSON")
+	}
+}
+
+func TestLintRunRulesAndResults(t *testing.T) {
+	report, err := parseTrunkCheckJSON([]byte(trunkCheckFixture))
+	if err != nil {
+		t.Fatalf("parseTrunkCheckJSON: %v", err)
+	}
+	run := lintRun("trunk", "1.22.0", report.Issues, report.Failures)
+	var ids []string
+	for _, r := range run.Tool.Driver.Rules {
+		ids = append(ids, r.ID)
+	}
+	want := []string{"markdownlint/MD013", "gomod", "eslint/prefer-const", "eslint/no-unused-vars"}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("rules = %v, want %v", ids, want)
+	}
+	if len(run.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(run.Results))
+	}
+	for _, r := range run.Results {
+		if run.Tool.Driver.Rules[*r.RuleIndex].ID != r.RuleID {
+			t.Fatalf("result %s points at rule %d", r.RuleID, *r.RuleIndex)
+		}
+		if _, ok := r.Properties["autofix_available"]; !ok {
+			t.Fatalf("result %s missing autofix_available", r.RuleID)
+		}
+	}
+	gomod := run.Results[1]
+	if gomod.Locations[0].PhysicalLocation.Region != nil || gomod.Level != "warning" {
+		t.Fatalf("expected a file-level warning for gomod, got %+v", gomod)
+	}
+	region := run.Results[3].Locations[0].PhysicalLocation.Region
+	if run.Results[3].RuleID != "eslint/no-unused-vars" || region == nil || region.StartLine != 12 || region.EndColumn != 9 {
+		t.Fatalf("unexpected region %+v for %s", region, run.Results[3].RuleID)
+	}
+	if run.Properties["tool_failures"] == nil {
+		t.Fatalf("expected tool failures in run properties")
+	}
+}
+
+func TestRunTrunkCheckWritesLintSARIF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	writeFile(t, dir, "issues.json", trunkCheckFixture)
+	stubPath := filepath.Join(dir, trunkExecutableName())
+	stub := "#!/bin/sh\necho \"$@\" > args.txt\ncat issues.json\nexit 1\n"
+	if err := os.WriteFile(stubPath, []byte(stub), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	cfg := &Config{TrunkPath: stubPath, Autofix: "none", LintSarifOut: filepath.Join("reports", "lint.sarif")}
+	cfg.logger = newEventLogger(io.Discard, false)
+	exitErr = nil
+	t.Cleanup(func() { exitErr = nil })
+
+	// Issues found are deferred to exit so later modes still run.
+	if err := runTrunkCheck(context.Background(), cfg); err != nil || exitErr == nil {
+		t.Fatalf("expected trunk's exit status to be deferred, got %v (exitErr %v)", err, exitErr)
+	}
+	if cfg.lintReport == nil || len(cfg.lintReport.Issues) != 5 {
+		t.Fatalf("expected the parsed report to be kept for --sarif-merge")
+	}
+	args, err := os.ReadFile("args.txt")
+	if err != nil {
+		t.Fatalf("read args: %v", err)
+	}
+	if string(args) != "check --output=json\n" {
+		t.Fatalf("unexpected trunk args %q", args)
+	}
+	data, err := os.ReadFile(cfg.LintSarifOut)
+	if err != nil {
+		t.Fatalf("read lint SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("decode lint SARIF: %v", err)
+	}
+	if len(log.Runs) != 1 || len(log.Runs[0].Results) != 5 || log.Runs[0].Tool.Driver.Name != "trunk" {
+		t.Fatalf("unexpected lint SARIF %+v", log.Runs)
+	}
+}
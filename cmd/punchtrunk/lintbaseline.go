@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Lint baseline.
+//
+// --lint-baseline ratchets lint on branches where trunk's hold-the-line has
+// no useful upstream to compare with. The file records one fingerprint per
+// issue: a hash of the file, linter, code and the flagged line with its
+// whitespace collapsed, so an issue still matches after code above it moves.
+// Later runs report, and gate on, only issues missing from the baseline, and
+// count baseline entries that no longer occur as fixed. A missing baseline is
+// created from the current issues; --update-baseline rewrites it. Untargeted
+// runs pass --all so hold-the-line cannot hide baseline issues outside the
+// diff; runs on explicit targets (targets.go) only compare and rewrite those
+// files' entries.
+
+const (
+	lintBaselineVersion = 1
+	lintFingerprintKey  = "punchtrunkLint/v1"
+)
+
+type lintBaselineFile struct {
+	Version int                 `json:"version"`
+	Issues  []lintBaselineEntry `json:"issues"`
+}
+
+type lintBaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+	Linter      string `json:"linter"`
+	Code        string `json:"code,omitempty"`
+	// Count is how many issues share the fingerprint, e.g. a repeated line.
+	Count int `json:"count"`
+}
+
+// lintBaselinePath validates the baseline flags and returns the path, or ""
+// when no baseline is in use.
+func (cfg *Config) lintBaselinePath() (string, error) {
+	if cfg == nil {
+		return "", nil
+	}
+	path := strings.TrimSpace(cfg.LintBaseline)
+	if path == "" && cfg.UpdateBaseline {
+		return "", errors.New("--update-baseline requires --lint-baseline")
+	}
+	return path, nil
+}
+
+// lintBaselineAll reports whether trunk check must run on every file: a
+// baseline compares against the whole repository, so hold-the-line would
+// record a partial baseline and count issues outside the diff as fixed.
+func (cfg *Config) lintBaselineAll() bool {
+	if cfg == nil || strings.TrimSpace(cfg.LintBaseline) == "" || cfg.targeted() {
+		return false
+	}
+	return !slices.Contains(cfg.TrunkArgs, "--all")
+}
+
+// applyLintBaseline fingerprints the report's issues and drops the ones the
+// baseline already records, creating or rewriting the baseline when asked.
+func applyLintBaseline(cfg *Config, report *lintReport) error {
+	path, err := cfg.lintBaselinePath()
+	if err != nil || path == "" {
+		return err
+	}
+	fingerprintLintIssues(report.Issues)
+	baseline, err := readLintBaseline(path)
+	missing := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !missing {
+		return err
+	}
+	var scope map[string]bool
+	if cfg.targeted() {
+		// Targets were resolved before trunk ran.
+		scope = map[string]bool{}
+		for _, f := range cfg.targets {
+			scope[f] = true
+		}
+	}
+	remaining := map[string]int{}
+	var untouched []lintBaselineEntry
+	if baseline != nil {
+		for _, e := range baseline.Issues {
+			if scope != nil && !scope[e.File] {
+				untouched = append(untouched, e)
+				continue
+			}
+			remaining[e.Fingerprint] += max(e.Count, 1)
+		}
+	}
+	var fresh []lintIssue
+	for _, issue := range report.Issues {
+		if remaining[issue.Fingerprint] > 0 {
+			remaining[issue.Fingerprint]--
+			report.Baselined++
+			continue
+		}
+		fresh = append(fresh, issue)
+	}
+	for _, n := range remaining {
+		report.Fixed += n
+	}
+	updated := missing || cfg.UpdateBaseline
+	if updated {
+		if err := writeLintBaseline(path, report.Issues, untouched); err != nil {
+			return err
+		}
+		report.Baselined += len(fresh)
+		fresh = nil
+	}
+	report.Issues = fresh
+	report.baseline = true
+	cfg.log().Event("info", "lint.baseline", LogFields{
+		"path":      path,
+		"new":       len(report.Issues),
+		"baselined": report.Baselined,
+		"fixed":     report.Fixed,
+		"updated":   updated,
+	})
+	return nil
+}
+
+func readLintBaseline(path string) (*lintBaselineFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var baseline lintBaselineFile
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("parse lint baseline %s: %w", path, err)
+	}
+	if baseline.Version != lintBaselineVersion {
+		return nil, fmt.Errorf("lint baseline %s has unsupported version %d", path, baseline.Version)
+	}
+	return &baseline, nil
+}
+
+// writeLintBaseline records issues, plus entries kept from the previous
+// baseline, sorted by file, linter and code so the file diffs cleanly under
+// review.
+func writeLintBaseline(path string, issues []lintIssue, keep []lintBaselineEntry) error {
+	counts := map[string]*lintBaselineEntry{}
+	for _, issue := range issues {
+		if e, ok := counts[issue.Fingerprint]; ok {
+			e.Count++
+			continue
+		}
+		counts[issue.Fingerprint] = &lintBaselineEntry{
+			Fingerprint: issue.Fingerprint,
+			File:        issue.File,
+			Linter:      issue.Linter,
+			Code:        issue.Code,
+			Count:       1,
+		}
+	}
+	baseline := lintBaselineFile{Version: lintBaselineVersion, Issues: append([]lintBaselineEntry{}, keep...)}
+	for _, e := range counts {
+		baseline.Issues = append(baseline.Issues, *e)
+	}
+	sort.Slice(baseline.Issues, func(i, j int) bool {
+		a, b := baseline.Issues[i], baseline.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Linter != b.Linter {
+			return a.Linter < b.Linter
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create lint baseline directory %s: %w", dir, err)
+		}
+	}
+	if err := writeIndentedJSON(path, &baseline); err != nil {
+		return fmt.Errorf("write lint baseline: %w", err)
+	}
+	return nil
+}
+
+// fingerprintLintIssues sets each issue's fingerprint from its flagged line.
+// File-level issues, and lines that can no longer be read, fall back to the
+// message.
+func fingerprintLintIssues(issues []lintIssue) {
+	lines := map[string][]string{}
+	for i := range issues {
+		issue := &issues[i]
+		content, ok := "", false
+		if issue.Line > 0 {
+			src, seen := lines[issue.File]
+			if !seen {
+				if data, err := os.ReadFile(filepath.FromSlash(issue.File)); err == nil {
+					src = strings.Split(string(data), "\n")
+				}
+				lines[issue.File] = src
+			}
+			if issue.Line <= len(src) {
+				content, ok = src[issue.Line-1], true
+			}
+		}
+		if !ok {
+			content = issue.Message
+		}
+		issue.Fingerprint = lintFingerprint(*issue, content)
+	}
+}
+
+func lintFingerprint(issue lintIssue, content string) string {
+	normalized := strings.Join(strings.Fields(content), " ")
+	sum := sha256.Sum256([]byte(strings.Join([]string{issue.File, issue.Linter, issue.Code, normalized}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestLintFingerprintSurvivesLineShifts(t *testing.T) {
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	writeFile(t, dir, "app.go", "package app\n\nvar  x = 1\n")
+	before := []lintIssue{{File: "app.go", Line: 3, Linter: "golangci-lint", Code: "unused", Message: "x is unused"}}
+	fingerprintLintIssues(before)
+
+	writeFile(t, dir, "app.go", "package app\n\n// added\nimport \"fmt\"\n\n\tvar x   = 1\n")
+	after := []lintIssue{{File: "app.go", Line: 6, Linter: "golangci-lint", Code: "unused", Message: "x is unused (moved)"}}
+	fingerprintLintIssues(after)
+	if before[0].Fingerprint == "" || before[0].Fingerprint != after[0].Fingerprint {
+		t.Fatalf("expected the fingerprint to survive a line shift, got %q and %q", before[0].Fingerprint, after[0].Fingerprint)
+	}
+
+	other := []lintIssue{{File: "app.go", Line: 6, Linter: "golangci-lint", Code: "deadcode", Message: "x is unused"}}
+	fingerprintLintIssues(other)
+	if other[0].Fingerprint == after[0].Fingerprint {
+		t.Fatalf("expected the code to be part of the fingerprint")
+	}
+}
+
+func TestApplyLintBaseline(t *testing.T) {
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	writeFile(t, dir, "a.sh", "echo $1\necho $1\nrm -rf $DIR\n")
+	issues := func(lines ...int) *lintReport {
+		report := &lintReport{}
+		for _, line := range lines {
+			report.Issues = append(report.Issues, lintIssue{File: "a.sh", Line: line, Linter: "shellcheck", Code: "SC2086", Message: "quote it"})
+		}
+		return report
+	}
+	cfg := &Config{LintBaseline: filepath.Join("ci", "lint-baseline.json")}
+	cfg.logger = newEventLogger(io.Discard, false)
+
+	// A missing baseline records the current issues.
+	first := issues(1, 2)
+	if err := applyLintBaseline(cfg, first); err != nil {
+		t.Fatalf("create baseline: %v", err)
+	}
+	if len(first.Issues) != 0 || first.Baselined != 2 {
+		t.Fatalf("expected both issues to be baselined, got %+v", first)
+	}
+	stored, err := readLintBaseline(cfg.LintBaseline)
+	if err != nil {
+		t.Fatalf("readLintBaseline: %v", err)
+	}
+	if len(stored.Issues) != 1 || stored.Issues[0].Count != 2 {
+		t.Fatalf("expected one entry for the repeated line, got %+v", stored.Issues)
+	}
+
+	// One repeated line fixed, one new issue.
+	second := issues(1, 3)
+	if err := applyLintBaseline(cfg, second); err != nil {
+		t.Fatalf("compare baseline: %v", err)
+	}
+	if len(second.Issues) != 1 || second.Issues[0].Line != 3 || second.Baselined != 1 || second.Fixed != 1 {
+		t.Fatalf("expected only line 3 to be new with one fixed, got %+v", second)
+	}
+	if got := second.summary(); got != "trunk check: 1 issues from 1 linters (new since baseline; 1 baselined, 1 fixed)" {
+		t.Fatalf("unexpected summary %q", got)
+	}
+	if r := lintResult(second.Issues[0], 0); r.PartialFingerprints[lintFingerprintKey] != second.Issues[0].Fingerprint {
+		t.Fatalf("expected the SARIF result to carry the fingerprint, got %v", r.PartialFingerprints)
+	}
+
+	cfg.UpdateBaseline = true
+	third := issues(1, 3)
+	if err := applyLintBaseline(cfg, third); err != nil {
+		t.Fatalf("update baseline: %v", err)
+	}
+	if len(third.Issues) != 0 || third.Baselined != 2 {
+		t.Fatalf("expected the update to baseline every issue, got %+v", third)
+	}
+	if stored, _ = readLintBaseline(cfg.LintBaseline); len(stored.Issues) != 2 {
+		t.Fatalf("expected the rewritten baseline to hold 2 entries, got %+v", stored.Issues)
+	}
+
+	// A run on explicit targets neither counts nor drops other files' entries.
+	writeFile(t, dir, "b.sh", "echo $1\n")
+	scoped := &Config{LintBaseline: cfg.LintBaseline, UpdateBaseline: true, Files: []string{"b.sh"}, targets: []string{"b.sh"}}
+	scoped.logger = newEventLogger(io.Discard, false)
+	fourth := &lintReport{Issues: []lintIssue{{File: "b.sh", Line: 1, Linter: "shellcheck", Code: "SC2086", Message: "quote it"}}}
+	if err := applyLintBaseline(scoped, fourth); err != nil {
+		t.Fatalf("scoped update: %v", err)
+	}
+	if fourth.Fixed != 0 {
+		t.Fatalf("expected untargeted entries not to count as fixed, got %d", fourth.Fixed)
+	}
+	if stored, _ = readLintBaseline(cfg.LintBaseline); len(stored.Issues) != 3 {
+		t.Fatalf("expected the scoped update to keep a.sh entries, got %+v", stored.Issues)
+	}
+
+	if _, err := (&Config{UpdateBaseline: true}).lintBaselinePath(); err == nil {
+		t.Fatalf("expected --update-baseline without --lint-baseline to be rejected")
+	}
+}
+
+func TestRunTrunkCheckBaselinePasses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	writeFile(t, dir, "issues.json", trunkCheckFixture)
+	stubPath := filepath.Join(dir, trunkExecutableName())
+	if err := os.WriteFile(stubPath, []byte("#!/bin/sh\ncat issues.json\nexit 1\n"), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	cfg := &Config{TrunkPath: stubPath, LintBaseline: "lint-baseline.json", LintSoftFail: "shellcheck"}
+	cfg.logger = newEventLogger(io.Discard, false)
+	exitErr = nil
+	t.Cleanup(func() { exitErr = nil })
+	for i := 0; i < 2; i++ {
+		if err := runTrunkCheck(context.Background(), cfg); err != nil || exitErr != nil {
+			t.Fatalf("run %d: expected baselined issues not to fail, got %v (exitErr %v)", i, err, exitErr)
+		}
+	}
+	if cfg.lintReport == nil || len(cfg.lintReport.Issues) != 0 || cfg.lintReport.Baselined != 5 {
+		t.Fatalf("expected all 5 issues to be baselined, got %+v", cfg.lintReport)
+	}
+}
+
+func TestRunTrunkCheckBaselineDisablesHoldTheLine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	writeFile(t, dir, "issues.json", trunkCheckFixture)
+	// Without --all the stub acts like hold-the-line and reports only the
+	// issue on a changed line.
+	writeFile(t, dir, "diff.json", `{"issues": [{"file": "README.md", "line": 40, "message": "Line length", "code": "MD013", "level": "LEVEL_LOW", "linter": "markdownlint"}]}`)
+	stubPath := filepath.Join(dir, trunkExecutableName())
+	stub := "#!/bin/sh\ncase \" $* \" in *\" --all \"*) cat issues.json ;; *) cat diff.json ;; esac\nexit 1\n"
+	if err := os.WriteFile(stubPath, []byte(stub), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	cfg := &Config{TrunkPath: stubPath, LintBaseline: "lint-baseline.json", LintSoftFail: "shellcheck"}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if args := trunkCheckArgs(cfg); !slices.Contains(args, "--all") {
+		t.Fatalf("expected --all with a lint baseline, got %v", args)
+	}
+	exitErr = nil
+	t.Cleanup(func() { exitErr = nil })
+	if err := runTrunkCheck(context.Background(), cfg); err != nil || exitErr != nil {
+		t.Fatalf("seed baseline: %v (exitErr %v)", err, exitErr)
+	}
+	stored, err := readLintBaseline(cfg.LintBaseline)
+	if err != nil || len(stored.Issues) != 5 {
+		t.Fatalf("expected the baseline to record all 5 issues, got %+v, %v", stored, err)
+	}
+
+	cfg.UpdateBaseline = true
+	if err := runTrunkCheck(context.Background(), cfg); err != nil || exitErr != nil {
+		t.Fatalf("update baseline: %v (exitErr %v)", err, exitErr)
+	}
+	if cfg.lintReport.Fixed != 0 || cfg.lintReport.Baselined != 5 {
+		t.Fatalf("expected no issue outside the diff to count as fixed, got %+v", cfg.lintReport)
+	}
+	if stored, err = readLintBaseline(cfg.LintBaseline); err != nil || len(stored.Issues) != 5 {
+		t.Fatalf("expected the rewritten baseline to keep all 5 issues, got %+v, %v", stored, err)
+	}
+
+	targeted := &Config{LintBaseline: "lint-baseline.json", Files: []string{"README.md"}}
+	if args := trunkCheckArgs(targeted); slices.Contains(args, "--all") {
+		t.Fatalf("expected explicit targets not to add --all, got %v", args)
+	}
+}
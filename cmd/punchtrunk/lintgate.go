@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Lint gating policy.
+//
+// trunk check's exit status alone cannot tell bad code from broken tooling.
+// Lint failures are classified into distinct exit codes: 1 when gating
+// issues were found, exitCodeToolFailure when trunk or a linter failed, and
+// exitCodeTimeout when --timeout expired. With parsed output (see lint.go),
+// --lint-fail-on limits gating issues to some levels, --lint-fail-linters to
+// some linters, and --lint-soft-fail lists linters whose issues and crashes
+// are reported but never fail the run. Without parsed output, trunk exit
+// status 1 counts as issues found and any other status as a tool failure.
+// Environment setup, dry-run, other modes, --sarif-merge and any unclassified
+// lint error (such as an invalid lint flag) are tool failures too, so exit 1
+// always means real findings.
+
+const (
+	exitCodeLintIssues  = 1
+	exitCodeToolFailure = 4
+	exitCodeTimeout     = 5
+
+	lintOutcomeIssues      = "issues"
+	lintOutcomeToolFailure = "tool_failure"
+	lintOutcomeTimeout     = "timeout"
+)
+
+// lintGateError is a classified lint failure; main exits with Code.
+type lintGateError struct {
+	Outcome string
+	Code    int
+	Err     error
+}
+
+func (e *lintGateError) Error() string {
+	return fmt.Sprintf("%s: %v", strings.ReplaceAll(e.Outcome, "_", " "), e.Err)
+}
+
+func (e *lintGateError) Unwrap() error { return e.Err }
+
+func newLintGateError(outcome string, err error) *lintGateError {
+	code := exitCodeLintIssues
+	switch outcome {
+	case lintOutcomeToolFailure:
+		code = exitCodeToolFailure
+	case lintOutcomeTimeout:
+		code = exitCodeTimeout
+	}
+	return &lintGateError{Outcome: outcome, Code: code, Err: err}
+}
+
+// exitCode maps a recorded failure to the process exit code.
+func exitCode(err error) int {
+	var gate *lintGateError
+	if errors.As(err, &gate) {
+		return gate.Code
+	}
+	return 1
+}
+
+type lintPolicy struct {
+	// FailOn lists SARIF levels that fail the run; empty means every level.
+	FailOn []string
+	// FailLinters limits gating to these linters; empty means every linter.
+	FailLinters []string
+	// SoftFail linters never fail the run.
+	SoftFail []string
+}
+
+var lintLevels = []string{"error", "warning", "note"}
+
+// lintPolicy validates the policy flags.
+func (cfg *Config) lintPolicy() (*lintPolicy, error) {
+	p := &lintPolicy{}
+	if cfg == nil {
+		return p, nil
+	}
+	for _, level := range splitCSV(cfg.LintFailOn) {
+		level = strings.ToLower(level)
+		if !slices.Contains(lintLevels, level) {
+			return nil, fmt.Errorf("unsupported lint-fail-on level %q (expected error, warning or note)", level)
+		}
+		p.FailOn = append(p.FailOn, level)
+	}
+	p.FailLinters = splitCSV(cfg.LintFailLinters)
+	p.SoftFail = splitCSV(cfg.LintSoftFail)
+	return p, nil
+}
+
+func (p *lintPolicy) active() bool {
+	return p != nil && (len(p.FailOn) > 0 || len(p.FailLinters) > 0 || len(p.SoftFail) > 0)
+}
+
+func (p *lintPolicy) describe() string {
+	var parts []string
+	if len(p.FailOn) > 0 {
+		parts = append(parts, "fail on "+strings.Join(p.FailOn, ","))
+	}
+	if len(p.FailLinters) > 0 {
+		parts = append(parts, "only "+strings.Join(p.FailLinters, ","))
+	}
+	if len(p.SoftFail) > 0 {
+		parts = append(parts, "soft-fail "+strings.Join(p.SoftFail, ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (p *lintPolicy) soft(linter string) bool {
+	return slices.Contains(p.SoftFail, linter)
+}
+
+// gates reports whether an issue fails the run.
+func (p *lintPolicy) gates(issue lintIssue) bool {
+	if p.soft(issue.Linter) {
+		return false
+	}
+	if len(p.FailLinters) > 0 && !slices.Contains(p.FailLinters, issue.Linter) {
+		return false
+	}
+	return len(p.FailOn) == 0 || slices.Contains(p.FailOn, issue.Level)
+}
+
+// evaluate classifies a parsed trunk check run. A timeout wins over tool
+// failures, which win over issues: an interrupted or broken run cannot vouch
+// for the code. It returns nil when nothing gates.
+func (p *lintPolicy) evaluate(ctx context.Context, report *lintReport, runErr error) (*lintGateError, LogFields) {
+	gating, soft := 0, 0
+	for _, issue := range report.Issues {
+		if p.gates(issue) {
+			gating++
+		} else {
+			soft++
+		}
+	}
+	var failed []string
+	for _, f := range report.Failures {
+		if !p.soft(f.Linter) {
+			failed = append(failed, f.Linter)
+		}
+	}
+	fields := LogFields{
+		"issues":        len(report.Issues),
+		"gating_issues": gating,
+		"soft_issues":   soft,
+		"tool_failures": len(report.Failures),
+		"hard_failures": len(failed),
+	}
+	var gateErr *lintGateError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		gateErr = newLintGateError(lintOutcomeTimeout, ctx.Err())
+	case len(failed) > 0:
+		gateErr = newLintGateError(lintOutcomeToolFailure, fmt.Errorf("%s failed", strings.Join(failed, ", ")))
+	case gating > 0:
+		gateErr = newLintGateError(lintOutcomeIssues, fmt.Errorf("%d gating lint issues", gating))
+	case runErr != nil && len(report.Issues) == 0 && report.Baselined == 0 && len(report.Failures) == 0:
+		// trunk failed without telling us why.
+		gateErr = newLintGateError(lintOutcomeToolFailure, runErr)
+	}
+	fields["passed"] = gateErr == nil
+	if gateErr != nil {
+		fields["outcome"] = gateErr.Outcome
+		fields["exit_code"] = gateErr.Code
+	}
+	return gateErr, fields
+}
+
+// toolFailure classifies a failure outside lint gating (environment setup,
+// fmt and other modes, --sarif-merge) as a tool failure or a timeout.
+func toolFailure(ctx context.Context, err error) *lintGateError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newLintGateError(lintOutcomeTimeout, err)
+	}
+	return newLintGateError(lintOutcomeToolFailure, err)
+}
+
+// classifyTrunkError maps a trunk check failure without parsed output.
+func classifyTrunkError(ctx context.Context, err error) *lintGateError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newLintGateError(lintOutcomeTimeout, err)
+	}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		return newLintGateError(lintOutcomeIssues, err)
+	}
+	return newLintGateError(lintOutcomeToolFailure, err)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLintPolicyValidation(t *testing.T) {
+	if _, err := (&Config{LintFailOn: "error,fatal"}).lintPolicy(); err == nil {
+		t.Fatalf("expected unsupported level to be rejected")
+	}
+	p, err := (&Config{LintFailOn: "Error, warning", LintSoftFail: "shellcheck"}).lintPolicy()
+	if err != nil {
+		t.Fatalf("lintPolicy: %v", err)
+	}
+	if !p.active() || p.describe() != "fail on error,warning; soft-fail shellcheck" {
+		t.Fatalf("unexpected policy %+v (%s)", p, p.describe())
+	}
+	if (&Config{}).lintCapture() {
+		t.Fatalf("expected no capture without lint SARIF, merge or policy flags")
+	}
+	if !(&Config{LintSoftFail: "shellcheck"}).lintCapture() {
+		t.Fatalf("expected a policy to capture trunk output")
+	}
+}
+
+func TestLintPolicyEvaluate(t *testing.T) {
+	report, err := parseTrunkCheckJSON([]byte(trunkCheckFixture))
+	if err != nil {
+		t.Fatalf("parseTrunkCheckJSON: %v", err)
+	}
+	runErr := errors.New("exit status 1")
+	ctx := context.Background()
+	cases := []struct {
+		name    string
+		cfg     *Config
+		outcome string
+	}{
+		{"default", &Config{}, lintOutcomeToolFailure},
+		{"soft crash", &Config{LintSoftFail: "shellcheck"}, lintOutcomeIssues},
+		{"errors only", &Config{LintSoftFail: "shellcheck", LintFailOn: "error"}, lintOutcomeIssues},
+		{"other linters", &Config{LintSoftFail: "shellcheck", LintFailLinters: "markdownlint", LintFailOn: "error"}, ""},
+		{"all soft", &Config{LintSoftFail: "shellcheck,eslint,markdownlint,gomod"}, ""},
+	}
+	for _, tc := range cases {
+		policy, err := tc.cfg.lintPolicy()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		gateErr, fields := policy.evaluate(ctx, report, runErr)
+		got := ""
+		if gateErr != nil {
+			got = gateErr.Outcome
+		}
+		if got != tc.outcome {
+			t.Fatalf("%s: outcome %q, want %q (%v)", tc.name, got, tc.outcome, fields)
+		}
+	}
+
+	deadline, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancel()
+	gateErr, _ := (&lintPolicy{}).evaluate(deadline, &lintReport{}, runErr)
+	if gateErr == nil || gateErr.Code != exitCodeTimeout {
+		t.Fatalf("expected a timeout, got %v", gateErr)
+	}
+	gateErr, _ = (&lintPolicy{}).evaluate(ctx, &lintReport{}, runErr)
+	if gateErr == nil || gateErr.Code != exitCodeToolFailure {
+		t.Fatalf("expected a failed run without issues to be a tool failure, got %v", gateErr)
+	}
+}
+
+func TestClassifyTrunkError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh not available on Windows")
+	}
+	ctx := context.Background()
+	issues := exec.Command("sh", "-c", "exit 1").Run()
+	crash := exec.Command("sh", "-c", "exit 7").Run()
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{issues, exitCodeLintIssues},
+		{crash, exitCodeToolFailure},
+		{exec.ErrNotFound, exitCodeToolFailure},
+	} {
+		if got := exitCode(classifyTrunkError(ctx, tc.err)); got != tc.code {
+			t.Fatalf("%v: exit code %d, want %d", tc.err, got, tc.code)
+		}
+	}
+	if exitCode(errors.New("plain")) != 1 {
+		t.Fatalf("expected unclassified errors to exit 1")
+	}
+}
+
+// mainExitCode re-runs test in a subprocess with env set to 1, where the test
+// calls main, and returns the exit code.
+func mainExitCode(t *testing.T, test, env string) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run="+test)
+	cmd.Env = append(os.Environ(), env+"=1")
+	cmd.Stderr = io.Discard
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		t.Fatalf("expected the run to exit non-zero, got %v", err)
+	}
+	return exit.ExitCode()
+}
+
+// lintRepo creates a one-commit repository with a trunk stub that passes and
+// changes into it.
+func lintRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitInit(t, dir)
+	writeFile(t, dir, "a.go", "package a\n")
+	gitAddCommit(t, dir, "initial")
+	stub := filepath.Join(dir, trunkExecutableName())
+	if err := os.WriteFile(stub, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	mustChdir(t, dir)
+	return stub
+}
+
+func TestMissingTrunkBinaryExitsWithToolFailure(t *testing.T) {
+	if os.Getenv("TEST_MISSING_TRUNK_EXIT") == "1" {
+		os.Args = []string{"punchtrunk", "--mode", "lint", "--trunk-binary", filepath.Join(t.TempDir(), "trunk")}
+		main()
+		return
+	}
+	if code := mainExitCode(t, "TestMissingTrunkBinaryExitsWithToolFailure", "TEST_MISSING_TRUNK_EXIT"); code != exitCodeToolFailure {
+		t.Fatalf("expected exit code %d for a missing trunk binary, got %d", exitCodeToolFailure, code)
+	}
+}
+
+func TestUnknownChangedSinceExitsWithToolFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	if os.Getenv("TEST_CHANGED_SINCE_EXIT") == "1" {
+		os.Args = []string{"punchtrunk", "--mode", "lint", "--trunk-binary", lintRepo(t), "--changed-since", "nosuchref"}
+		main()
+		return
+	}
+	if code := mainExitCode(t, "TestUnknownChangedSinceExitsWithToolFailure", "TEST_CHANGED_SINCE_EXIT"); code != exitCodeToolFailure {
+		t.Fatalf("expected exit code %d for an unknown --changed-since ref, got %d", exitCodeToolFailure, code)
+	}
+}
+
+func TestInvalidLintFlagExitsWithToolFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	if os.Getenv("TEST_INVALID_LINT_FLAG_EXIT") == "1" {
+		os.Args = []string{"punchtrunk", "--mode", "lint", "--trunk-binary", lintRepo(t), "--update-baseline"}
+		main()
+		return
+	}
+	if code := mainExitCode(t, "TestInvalidLintFlagExitsWithToolFailure", "TEST_INVALID_LINT_FLAG_EXIT"); code != exitCodeToolFailure {
+		t.Fatalf("expected exit code %d for --update-baseline without --lint-baseline, got %d", exitCodeToolFailure, code)
+	}
+}
+
+func TestToolFailureClassifiesTimeout(t *testing.T) {
+	if got := exitCode(toolFailure(context.Background(), errors.New("boom"))); got != exitCodeToolFailure {
+		t.Fatalf("expected tool failure exit code, got %d", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if got := exitCode(toolFailure(ctx, errors.New("killed"))); got != exitCodeTimeout {
+		t.Fatalf("expected timeout exit code, got %d", got)
+	}
+}
+
+func TestRunTrunkCheckSoftFailPasses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	writeFile(t, dir, "issues.json", trunkCheckFixture)
+	stubPath := filepath.Join(dir, trunkExecutableName())
+	if err := os.WriteFile(stubPath, []byte("#!/bin/sh\ncat issues.json\nexit 1\n"), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	cfg := &Config{TrunkPath: stubPath, LintSoftFail: "shellcheck", LintFailOn: "error", LintFailLinters: "markdownlint"}
+	cfg.logger = newEventLogger(io.Discard, false)
+	exitErr = nil
+	t.Cleanup(func() { exitErr = nil })
+	if err := runTrunkCheck(context.Background(), cfg); err != nil || exitErr != nil {
+		t.Fatalf("expected the policy to pass, got %v (exitErr %v)", err, exitErr)
+	}
+
+	cfg.LintFailLinters = ""
+	if err := runTrunkCheck(context.Background(), cfg); err != nil || exitCode(exitErr) != exitCodeLintIssues {
+		t.Fatalf("expected eslint errors to fail with exit 1, got %v (exitErr %v)", err, exitErr)
+	}
+}
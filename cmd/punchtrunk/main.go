@@ -11,6 +11,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -23,6 +25,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -173,10 +176,84 @@ type Config struct {
 	ManifestPath       string
 	ToolHealthFormat   string
 	ToolHealthJSONPath string
-	logger             *eventLogger
-	tmpDirResolved     string
-	tmpDirErr          error
-	tmpDirOnce         sync.Once
+	HotspotSince       string
+	HotspotUntil       string
+	HotspotMaxCommits  int
+	HotspotChurnModel  string
+	HotspotHalfLife    string
+	HotspotComplexity  string
+	HotspotRegionLimit int
+	HotspotInclude     []string
+	HotspotExclude     []string
+	// HotspotIncludeGenerated keeps generated, vendored and binary files in
+	// hotspot rankings; they are skipped by default.
+	HotspotIncludeGenerated bool
+	// HotspotTiers maps hotspot ranks to SARIF levels, e.g. "error=top10,warning=p90".
+	HotspotTiers string
+	// HotspotBaseline points at a previous hotspots SARIF or JSON report to
+	// diff against.
+	HotspotBaseline string
+	// HotspotFailOn opts into the hotspot quality gate, e.g. "score=8,top=10,rise=5%".
+	HotspotFailOn string
+	// Coupling mode settings; the churn window flags above also bound it.
+	CouplingOut           string
+	CouplingMinSupport    int
+	CouplingMinConfidence float64
+	CouplingMaxFiles      int
+	// HotspotJSON and HotspotTreemap are optional extra report paths.
+	HotspotJSON         string
+	HotspotTreemap      string
+	HotspotRollupDepth  int
+	HotspotRollupPrefix []string
+	// HotspotFormat lists report formats: sarif,json,csv,markdown.
+	HotspotFormat string
+	// HotspotLimit caps reported hotspots (0 uses the default of 500);
+	// HotspotMinScore (empty for none) and HotspotMinChurn drop weak results.
+	HotspotLimit    int
+	HotspotMinScore string
+	HotspotMinChurn int
+	// HotspotScope is "repo" (default) or "changed" to report only files in
+	// the base...HEAD diff, keeping their repo-wide rank.
+	HotspotScope string
+	// GitBackend selects how hotspot history is read: auto, go-git or cli.
+	GitBackend string
+	// HotspotHTML is an optional self-contained HTML report path.
+	HotspotHTML string
+	// HotspotCache reuses per-commit numstat and complexity results from
+	// earlier runs.
+	HotspotCache bool
+	// LintSarifOut, when set, makes lint mode parse trunk's JSON output and
+	// write the issues as SARIF.
+	LintSarifOut string
+	// SarifMerge, when set, is where one SARIF log combining the lint runs
+	// and the hotspots run is written after all modes finish.
+	SarifMerge string
+	// Lint gating policy: levels and linters that fail the run, and linters
+	// that only warn.
+	LintFailOn      string
+	LintFailLinters string
+	LintSoftFail    string
+	// LintBaseline is a file of known lint issue fingerprints; only issues
+	// missing from it are reported. UpdateBaseline rewrites it.
+	LintBaseline   string
+	UpdateBaseline bool
+	// Files, FilesFrom and ChangedSince give fmt and lint an explicit target
+	// list instead of trunk's change detection.
+	Files          []string
+	FilesFrom      string
+	ChangedSince   string
+	logger         *eventLogger
+	tmpDirResolved string
+	tmpDirErr      error
+	tmpDirOnce     sync.Once
+	cache          *hotspotCache
+	cacheOnce      sync.Once
+	targets        []string
+	targetsErr     error
+	targetsOnce    sync.Once
+	// lintReport and hotspotReport keep this run's results for --sarif-merge.
+	lintReport    *lintReport
+	hotspotReport *hotspotReport
 }
 
 type trunkYAML struct {
@@ -254,7 +331,8 @@ func main() {
 
 	if cfg.DryRun {
 		if err := executeDryRun(cfg); err != nil {
-			cfg.log().Fatalf("dry-run failed: %v", err)
+			cfg.log().Errorf("dry-run failed: %v", err)
+			os.Exit(exitCode(toolFailure(context.Background(), err)))
 		}
 		return
 	}
@@ -285,7 +363,8 @@ func main() {
 
 	if needsEnvironment {
 		if err := ensureEnvironment(ctx, cfg); err != nil {
-			cfg.log().Fatalf("environment setup failed: %v", err)
+			cfg.log().Errorf("environment setup failed: %v", err)
+			os.Exit(exitCode(toolFailure(ctx, err)))
 		}
 		cfg.log().Event("info", "environment.ready", LogFields{
 			"trunk_path": cfg.TrunkPath,
@@ -313,6 +392,8 @@ func main() {
 			err = runTrunkCheck(ctx, cfg)
 		case "hotspots":
 			err = runHotspots(ctx, cfg)
+		case "coupling":
+			err = runCoupling(ctx, cfg)
 		case "diagnose-airgap":
 			err = runDiagnoseAirgap(cfg)
 		case "tool-health":
@@ -331,11 +412,18 @@ func main() {
 				"duration_ms": duration.Milliseconds(),
 				"error":       err.Error(),
 			})
-			if mode == "hotspots" {
+			if mode == "hotspots" || mode == "coupling" {
 				cfg.log().Warnf("%s failed: %v", mode, err)
 				continue
 			}
-			cfg.log().Fatalf("%s failed: %v", mode, err)
+			cfg.log().Errorf("%s failed: %v", mode, err)
+			// Only a classified lint failure may exit 1 for issues found;
+			// everything else, including invalid lint flags, is a tool failure.
+			var gate *lintGateError
+			if mode != "lint" || !errors.As(err, &gate) {
+				gate = toolFailure(ctx, err)
+			}
+			os.Exit(gate.Code)
 		}
 		duration := time.Since(modeStart)
 		cfg.log().Event("info", "mode.finish", LogFields{
@@ -345,8 +433,18 @@ func main() {
 		})
 	}
 
+	if cfg.SarifMerge != "" {
+		if err := writeMergedSARIF(cfg); err != nil {
+			cfg.log().Errorf("sarif merge failed: %v", err)
+			os.Exit(exitCode(toolFailure(ctx, err)))
+		}
+	}
+
 	if exitErr != nil {
-		os.Exit(1)
+		os.Exit(exitCode(exitErr))
+	}
+	if hotspotGateErr != nil {
+		os.Exit(exitCodeHotspotGate)
 	}
 }
 
@@ -445,7 +543,46 @@ func parseFlags() *Config {
 	var trunkArgs multiFlag
 	var toolHealthFormat string
 	var toolHealthJSON string
-	flag.StringVar(&modes, "mode", "fmt,lint,hotspots", "Comma-separated phases: fmt,lint,hotspots")
+	var hotspotSince string
+	var hotspotUntil string
+	var hotspotMaxCommits int
+	var hotspotChurnModel string
+	var hotspotHalfLife string
+	var hotspotComplexity string
+	var hotspotRegionLimit int
+	var hotspotInclude multiFlag
+	var hotspotExclude multiFlag
+	var hotspotIncludeGenerated bool
+	var hotspotTiers string
+	var hotspotBaseline string
+	var hotspotFailOn string
+	var couplingOut string
+	var couplingMinSupport int
+	var couplingMinConfidence float64
+	var couplingMaxFiles int
+	var hotspotJSON string
+	var hotspotTreemap string
+	var hotspotRollupDepth int
+	var hotspotRollupPrefix multiFlag
+	var hotspotFormat string
+	var hotspotHTML string
+	var hotspotLimit int
+	var hotspotMinScore string
+	var hotspotMinChurn int
+	var hotspotScope string
+	var gitBackend string
+	var hotspotCache bool
+	var lintSarifOut string
+	var sarifMerge string
+	var lintFailOn string
+	var lintFailLinters string
+	var lintSoftFail string
+	var lintBaseline string
+	var updateBaseline bool
+	var files multiFlag
+	var filesFrom string
+	var changedSince string
+	flag.StringVar(&modes, "mode", "fmt,lint,hotspots", "Comma-separated phases: fmt,lint,hotspots,coupling")
 	flag.StringVar(&autofix, "autofix", "fmt", "Autofix scope: none|fmt|lint|all")
 	flag.StringVar(&base, "base-branch", "origin/main", "Base branch for change detection")
 	flag.IntVar(&maxProcs, "max-procs", 0, "Parallelism cap (0 = CPU cores)")
@@ -461,6 +598,45 @@ func parseFlags() *Config {
 	flag.Var(&trunkArgs, "trunk-arg", "Additional argument to pass to trunk CLI (repeatable)")
 	flag.StringVar(&toolHealthFormat, "tool-health-format", "json", "Output format for tool-health: json|summary")
 	flag.StringVar(&toolHealthJSON, "tool-health-json", "", "Optional file path to write tool-health JSON report")
+	flag.StringVar(&hotspotSince, "hotspot-since", defaultHotspotSince, "Start of the hotspot churn window: date, duration (e.g. 14d, 336h) or commit ref")
+	flag.StringVar(&hotspotUntil, "hotspot-until", "", "End of the hotspot churn window: date, duration or commit ref (default: HEAD)")
+	flag.IntVar(&hotspotMaxCommits, "hotspot-max-commits", 0, "Maximum commits to walk for hotspot churn (0 = unlimited)")
+	flag.StringVar(&hotspotChurnModel, "hotspot-churn-model", churnModelLinear, "Churn weighting for hotspot scores: linear|decay")
+	flag.StringVar(&hotspotHalfLife, "hotspot-half-life", defaultHotspotHalfLife, "Half-life for decay churn weighting (e.g. 30d, 2w, 720h)")
+	flag.StringVar(&hotspotComplexity, "hotspot-complexity", complexityModeAuto, "Complexity estimator: auto (language-aware) or density (legacy tokens per line)")
+	flag.IntVar(&hotspotRegionLimit, "hotspot-regions", defaultHotspotRegionLimit, "Attribute churn to functions/line ranges for the top N hotspots via git blame (0 disables)")
+	flag.Var(&hotspotInclude, "hotspot-include", "Glob of paths to keep in hotspot rankings (repeatable or comma-separated)")
+	flag.Var(&hotspotExclude, "hotspot-exclude", "Glob of paths to drop from hotspot rankings (repeatable or comma-separated)")
+	flag.BoolVar(&hotspotIncludeGenerated, "hotspot-include-generated", false, "Keep generated, vendored and binary files in hotspot rankings")
+	flag.StringVar(&hotspotTiers, "hotspot-tiers", "", "Map hotspots to SARIF levels, e.g. error=top10,warning=p90,note=2.5 (percentile pN, rank topN or minimum score; default: all note)")
+	flag.StringVar(&hotspotBaseline, "hotspot-baseline", "", "Previous hotspots SARIF or JSON report to diff against (new/rising/cooling/resolved)")
+	flag.StringVar(&hotspotFailOn, "hotspot-fail-on", "", "Fail (exit 3) when a changed file hits score=N or top=N, or total score rises past rise=P% of --hotspot-baseline")
+	flag.StringVar(&couplingOut, "coupling-out", defaultCouplingOut, "Where to write the coupling report (.json for JSON, otherwise SARIF)")
+	flag.IntVar(&couplingMinSupport, "coupling-min-support", defaultCouplingMinSupport, "Minimum shared commits before a file pair is reported as coupled")
+	flag.Float64Var(&couplingMinConfidence, "coupling-min-confidence", defaultCouplingMinConfidence, "Minimum share (0-1] of a file's commits that also change its partner")
+	flag.IntVar(&couplingMaxFiles, "coupling-max-files", defaultCouplingMaxFiles, "Ignore commits touching more files than this when mining coupling")
+	flag.StringVar(&hotspotJSON, "hotspot-json", "", "Also write hotspots with directory/module/prefix rollups as JSON to this path")
+	flag.StringVar(&hotspotTreemap, "hotspot-treemap", "", "Also write a nested name/children/value treemap JSON of hotspot scores to this path")
+	flag.IntVar(&hotspotRollupDepth, "hotspot-rollup-depth", defaultHotspotRollupDepth, "Directory depth used for hotspot directory rollups")
+	flag.Var(&hotspotRollupPrefix, "hotspot-rollup-prefix", "Path prefix to roll hotspots up under, e.g. services/api (repeatable or comma-separated)")
+	flag.IntVar(&hotspotLimit, "hotspot-limit", defaultHotspotLimit, "Maximum number of hotspots to report (0 uses the default); files are still ranked repo-wide")
+	flag.StringVar(&hotspotMinScore, "hotspot-min-score", "", "Drop hotspots scoring below this value (default: no minimum)")
+	flag.IntVar(&hotspotMinChurn, "hotspot-min-churn", 0, "Drop hotspots with fewer churned lines than this")
+	flag.StringVar(&hotspotScope, "hotspot-scope", hotspotScopeRepo, "Hotspots to report: repo (all files) or changed (files in base...HEAD, with repo-wide rank)")
+	flag.StringVar(&gitBackend, "git-backend", gitBackendAuto, "How hotspots read git history: auto (in-process go-git, falling back to the git CLI), go-git or cli")
+	flag.StringVar(&lintSarifOut, "lint-sarif-out", "", "Run trunk check with JSON output and write its issues as SARIF to this path")
+	flag.StringVar(&lintFailOn, "lint-fail-on", "", "Lint levels that fail the run, comma-separated: error,warning,note (default: all)")
+	flag.StringVar(&lintFailLinters, "lint-fail-linters", "", "Only issues from these linters fail the run (comma-separated; default: all)")
+	flag.StringVar(&lintSoftFail, "lint-soft-fail", "", "Linters whose issues and crashes are reported but never fail the run (comma-separated)")
+	flag.StringVar(&lintBaseline, "lint-baseline", "", "File of known lint issue fingerprints; only new issues are reported and gated (created when missing)")
+	flag.BoolVar(&updateBaseline, "update-baseline", false, "Rewrite --lint-baseline from the current issues")
+	flag.Var(&files, "files", "Run fmt and lint on these files instead of trunk's change detection (repeatable or comma-separated)")
+	flag.StringVar(&filesFrom, "files-from", "", "Read fmt and lint target files from this path, one per line or NUL-separated ('-' for stdin)")
+	flag.StringVar(&changedSince, "changed-since", "", "Run fmt and lint on files changed in <ref>...HEAD (existing files only)")
+	flag.StringVar(&sarifMerge, "sarif-merge", "", "Also write one SARIF log with a run per trunk linter plus the hotspots run to this path")
+	flag.BoolVar(&hotspotCache, "hotspot-cache", true, "Cache per-commit numstat and complexity results under the Trunk cache (or --tmp-dir) so later runs only diff new commits")
+	flag.StringVar(&hotspotHTML, "hotspot-html", "", "Also write a self-contained HTML hotspot report (table, scatter plot, directory rollups) to this path")
+	flag.StringVar(&hotspotFormat, "hotspot-format", defaultHotspotFormat, "Hotspot report formats, comma-separated: sarif,json,csv,markdown (non-SARIF files sit next to --sarif-out)")
 	flag.Parse()
 
 	envTrunkBinary := os.Getenv("PUNCHTRUNK_TRUNK_BINARY")
@@ -493,22 +669,61 @@ func parseFlags() *Config {
 	}
 
 	return &Config{
-		Modes:              modeList,
-		Autofix:            strings.ToLower(strings.TrimSpace(autofix)),
-		BaseBranch:         base,
-		MaxProcs:           maxProcs,
-		Timeout:            timeout,
-		SarifOut:           filepath.Clean(sarifOut),
-		Verbose:            verbose,
-		JSONLogs:           jsonLogs,
-		DryRun:             dryRun,
-		TmpDir:             strings.TrimSpace(tmpDir),
-		ShowVersion:        version,
-		TrunkConfigDir:     trunkConfigDir,
-		TrunkArgs:          trunkArgs,
-		TrunkBinary:        trunkBinary,
-		ToolHealthFormat:   strings.TrimSpace(toolHealthFormat),
-		ToolHealthJSONPath: strings.TrimSpace(toolHealthJSON),
+		Modes:                   modeList,
+		Autofix:                 strings.ToLower(strings.TrimSpace(autofix)),
+		BaseBranch:              base,
+		MaxProcs:                maxProcs,
+		Timeout:                 timeout,
+		SarifOut:                filepath.Clean(sarifOut),
+		Verbose:                 verbose,
+		JSONLogs:                jsonLogs,
+		DryRun:                  dryRun,
+		TmpDir:                  strings.TrimSpace(tmpDir),
+		ShowVersion:             version,
+		TrunkConfigDir:          trunkConfigDir,
+		TrunkArgs:               trunkArgs,
+		TrunkBinary:             trunkBinary,
+		ToolHealthFormat:        strings.TrimSpace(toolHealthFormat),
+		ToolHealthJSONPath:      strings.TrimSpace(toolHealthJSON),
+		HotspotSince:            strings.TrimSpace(hotspotSince),
+		HotspotUntil:            strings.TrimSpace(hotspotUntil),
+		HotspotMaxCommits:       hotspotMaxCommits,
+		HotspotChurnModel:       strings.ToLower(strings.TrimSpace(hotspotChurnModel)),
+		HotspotHalfLife:         strings.TrimSpace(hotspotHalfLife),
+		HotspotComplexity:       strings.ToLower(strings.TrimSpace(hotspotComplexity)),
+		HotspotRegionLimit:      hotspotRegionLimit,
+		HotspotInclude:          hotspotInclude,
+		HotspotExclude:          hotspotExclude,
+		HotspotIncludeGenerated: hotspotIncludeGenerated,
+		HotspotTiers:            hotspotTiers,
+		HotspotBaseline:         hotspotBaseline,
+		HotspotFailOn:           hotspotFailOn,
+		CouplingOut:             couplingOut,
+		CouplingMinSupport:      couplingMinSupport,
+		CouplingMinConfidence:   couplingMinConfidence,
+		CouplingMaxFiles:        couplingMaxFiles,
+		HotspotJSON:             hotspotJSON,
+		HotspotTreemap:          hotspotTreemap,
+		HotspotRollupDepth:      hotspotRollupDepth,
+		HotspotRollupPrefix:     hotspotRollupPrefix,
+		HotspotFormat:           hotspotFormat,
+		HotspotHTML:             hotspotHTML,
+		HotspotLimit:            hotspotLimit,
+		HotspotMinScore:         hotspotMinScore,
+		HotspotMinChurn:         hotspotMinChurn,
+		HotspotScope:            hotspotScope,
+		GitBackend:              strings.ToLower(strings.TrimSpace(gitBackend)),
+		HotspotCache:            hotspotCache,
+		LintSarifOut:            strings.TrimSpace(lintSarifOut),
+		SarifMerge:              strings.TrimSpace(sarifMerge),
+		LintFailOn:              lintFailOn,
+		LintFailLinters:         lintFailLinters,
+		LintSoftFail:            lintSoftFail,
+		LintBaseline:            strings.TrimSpace(lintBaseline),
+		UpdateBaseline:          updateBaseline,
+		Files:                   files,
+		FilesFrom:               strings.TrimSpace(filesFrom),
+		ChangedSince:            strings.TrimSpace(changedSince),
 	}
 }
 
@@ -557,6 +772,12 @@ func trunkCheckArgs(cfg *Config) []string {
 	default:
 		// treat unknown as none
 	}
+	if cfg.lintCapture() {
+		args = append(args, trunkOutputJSON)
+	}
+	if cfg.lintBaselineAll() {
+		args = append(args, "--all")
+	}
 	if cfg != nil {
 		args = append(args, cfg.TrunkArgs...)
 	}
@@ -564,80 +785,134 @@ func trunkCheckArgs(cfg *Config) []string {
 }
 
 func runTrunkFmt(ctx context.Context, cfg *Config) error {
-	args := trunkFmtArgs(cfg)
-	cmd := exec.CommandContext(ctx, cfg.trunkBinary(), args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	applyTrunkCommandEnv(cmd, cfg)
+	batches, err := trunkArgBatches(ctx, cfg, trunkFmtArgs(cfg))
+	if err != nil {
+		return err
+	}
 	maybeWarnCompetingTools("fmt", cfg)
-	if cfg.Verbose {
-		cfg.log().Infof("Running: %s %s", cfg.trunkBinary(), strings.Join(args, " "))
+	if len(batches) == 0 {
+		cfg.log().Infof("fmt: no target files; skipping trunk fmt")
+		return nil
+	}
+	for _, args := range batches {
+		cmd := exec.CommandContext(ctx, cfg.trunkBinary(), args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		applyTrunkCommandEnv(cmd, cfg)
+		if cfg.Verbose {
+			cfg.log().Infof("Running: %s %s", cfg.trunkBinary(), strings.Join(args, " "))
+		}
+		if err := cmd.Run(); err != nil {
+			return err
+		}
 	}
-	return cmd.Run()
+	return nil
 }
 
 func runTrunkCheck(ctx context.Context, cfg *Config) error {
-	args := trunkCheckArgs(cfg)
-	// Let trunk decide changed files via hold-the-line; base branch is read from trunk.yaml.
-	cmd := exec.CommandContext(ctx, cfg.trunkBinary(), args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	applyTrunkCommandEnv(cmd, cfg)
+	policy, err := cfg.lintPolicy()
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.lintBaselinePath(); err != nil {
+		return err
+	}
+	batches, err := trunkArgBatches(ctx, cfg, trunkCheckArgs(cfg))
+	if err != nil {
+		// An unknown --changed-since ref or unreadable --files-from is a
+		// tool failure, not lint issues.
+		return toolFailure(ctx, err)
+	}
 	maybeWarnCompetingTools("lint", cfg)
-	if cfg.Verbose {
-		cfg.log().Infof("Running: %s %s", cfg.trunkBinary(), strings.Join(args, " "))
+	if len(batches) == 0 {
+		cfg.log().Infof("lint: no target files; skipping trunk check")
+	}
+	if cfg.lintCapture() {
+		report, runErr := runTrunkCheckCaptured(ctx, cfg, batches)
+		cfg.lintReport = report
+		if report != nil {
+			// The policy decides; a failure is deferred to exit so later
+			// modes and the merged SARIF still run.
+			gateErr, fields := policy.evaluate(ctx, report, runErr)
+			cfg.log().Event("info", "lint.policy", fields)
+			if gateErr != nil {
+				exitErr = gateErr
+			}
+			return nil
+		}
+		err = runErr
+	} else {
+		// Without explicit targets trunk decides changed files via
+		// hold-the-line; base branch is read from trunk.yaml. Every batch
+		// runs; the first failure is kept.
+		for _, args := range batches {
+			if cfg.Verbose {
+				cfg.log().Infof("Running: %s %s", cfg.trunkBinary(), strings.Join(args, " "))
+			}
+			cmd := exec.CommandContext(ctx, cfg.trunkBinary(), args...)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			applyTrunkCommandEnv(cmd, cfg)
+			if runErr := cmd.Run(); runErr != nil && err == nil {
+				err = runErr
+			}
+		}
 	}
-	err := cmd.Run()
 	if err != nil {
-		exitErr = err
+		gateErr := classifyTrunkError(ctx, err)
+		exitErr = gateErr
+		return gateErr
 	}
-	return err
+	return nil
 }
 
 func runHotspots(ctx context.Context, cfg *Config) error {
-	hs, err := computeHotspots(ctx, cfg)
+	report, err := analyzeHotspots(ctx, cfg)
+	enforceHotspotGate(cfg, report, err)
 	if err != nil {
 		return err
 	}
-	if cfg.SarifOut == "" {
-		if cfg.Verbose {
-			cfg.log().Warnf("Hotspots computed (%d results) but SARIF output path is empty", len(hs))
+	cfg.hotspotReport = report
+	formats, err := parseHotspotFormats(cfg.HotspotFormat)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = writeHotspotReports(cfg, report, hotspotOutputs(cfg, formats))
+	logPhase(cfg, phaseSerialization, start, LogFields{"formats": strings.Join(formats, ",")})
+	return err
+}
+
+// prepareReportPath creates the parent directory of a report file and
+// returns the path to write, redirected to the tmp fallback when the
+// workspace is read-only or the directory cannot be created.
+func prepareReportPath(cfg *Config, path string) (string, error) {
+	dir := filepath.Dir(path)
+	if dir == "." {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fallback, ok := sarifFallbackPath(cfg, path, err)
+		if !ok {
+			return "", fmt.Errorf("create SARIF directory %s: %w", dir, err)
 		}
-		return nil
+		cfg.log().Warnf("unable to create SARIF directory %s: %v; writing to %s instead", dir, err, fallback)
+		if err := os.MkdirAll(filepath.Dir(fallback), 0o755); err != nil {
+			return "", fmt.Errorf("create fallback SARIF directory %s: %w", filepath.Dir(fallback), err)
+		}
+		return fallback, nil
 	}
-	dir := filepath.Dir(cfg.SarifOut)
-	if dir != "." {
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			fallback, ok := sarifFallbackPath(cfg, cfg.SarifOut, err)
-			if !ok {
-				return fmt.Errorf("create SARIF directory %s: %w", dir, err)
-			}
-			cfg.log().Warnf("unable to create SARIF directory %s: %v; writing to %s instead", dir, err, fallback)
-			cfg.SarifOut = fallback
-			dir = filepath.Dir(cfg.SarifOut)
-			if err := os.MkdirAll(dir, 0o755); err != nil {
-				return fmt.Errorf("create fallback SARIF directory %s: %w", dir, err)
-			}
-		} else if hasReadOnlyAncestor(dir) {
-			fallback, ok := sarifFallbackPath(cfg, cfg.SarifOut, errReadOnlyWorkspace)
-			if ok {
-				cfg.log().Warnf("detected read-only workspace for SARIF directory %s; writing to %s instead", dir, fallback)
-				cfg.SarifOut = fallback
-				dir = filepath.Dir(cfg.SarifOut)
-				if err := os.MkdirAll(dir, 0o755); err != nil {
-					return fmt.Errorf("create fallback SARIF directory %s: %w", dir, err)
-				}
+	if hasReadOnlyAncestor(dir) {
+		fallback, ok := sarifFallbackPath(cfg, path, errReadOnlyWorkspace)
+		if ok {
+			cfg.log().Warnf("detected read-only workspace for SARIF directory %s; writing to %s instead", dir, fallback)
+			if err := os.MkdirAll(filepath.Dir(fallback), 0o755); err != nil {
+				return "", fmt.Errorf("create fallback SARIF directory %s: %w", filepath.Dir(fallback), err)
 			}
+			return fallback, nil
 		}
 	}
-	if err := writeSARIF(cfg.SarifOut, hs); err != nil {
-		return err
-	}
-	cfg.log().Event("info", "sarif.write", LogFields{
-		"sarif_out": cfg.SarifOut,
-		"count":     len(hs),
-	})
-	return nil
+	return path, nil
 }
 
 var errReadOnlyWorkspace = errors.New("read-only workspace detected")
@@ -821,16 +1096,106 @@ func buildDryRunPlan(cfg *Config) (*dryRunPlan, error) {
 			args := trunkFmtArgs(cfg)
 			modePlan.Command = prependCommand(plan.Trunk.displayCommand(), args)
 			modePlan.Description = "format code via trunk fmt"
+			if cfg.targeted() {
+				modePlan.Description += "; " + cfg.describeTargets()
+			}
 		case "lint":
 			args := trunkCheckArgs(cfg)
 			modePlan.Command = prependCommand(plan.Trunk.displayCommand(), args)
 			modePlan.Description = "run trunk lint checks"
+			if cfg.targeted() {
+				modePlan.Description += "; " + cfg.describeTargets()
+			}
+			if strings.TrimSpace(cfg.LintSarifOut) != "" {
+				modePlan.Description += fmt.Sprintf(" and write lint SARIF to %s", cfg.LintSarifOut)
+			}
+			if cfg.SarifMerge != "" {
+				modePlan.Description += fmt.Sprintf("; merge findings into %s", cfg.SarifMerge)
+			}
+			if policy, err := cfg.lintPolicy(); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			} else if policy.active() {
+				modePlan.Description += "; " + policy.describe()
+			}
+			if path, err := cfg.lintBaselinePath(); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			} else if path != "" && cfg.UpdateBaseline {
+				modePlan.Description += fmt.Sprintf("; rewrite lint baseline %s", path)
+			} else if path != "" {
+				modePlan.Description += fmt.Sprintf("; report only issues missing from lint baseline %s", path)
+			}
 		case "hotspots":
-			if strings.TrimSpace(cfg.SarifOut) != "" {
-				modePlan.Description = fmt.Sprintf("compute hotspots and write SARIF to %s", cfg.SarifOut)
+			window := cfg.churnWindow()
+			settings := window.describe()
+			if model, err := cfg.churnModel(); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			} else {
+				settings = fmt.Sprintf("%s, %s", settings, model.describe())
+			}
+			formats, err := parseHotspotFormats(cfg.HotspotFormat)
+			if err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			}
+			switch {
+			case err == nil && !slices.Contains(formats, hotspotFormatSARIF):
+				modePlan.Description = fmt.Sprintf("compute hotspots (%s; SARIF not requested)", settings)
+			case strings.TrimSpace(cfg.SarifOut) != "":
+				modePlan.Description = fmt.Sprintf("compute hotspots (%s) and write SARIF to %s", settings, cfg.SarifOut)
+			default:
+				modePlan.Description = fmt.Sprintf("compute hotspots (%s; no SARIF destination configured)", settings)
+			}
+			for _, format := range formats {
+				if format == hotspotFormatSARIF {
+					continue
+				}
+				path := hotspotOutputPath(cfg.SarifOut, format)
+				if format == hotspotFormatJSON && strings.TrimSpace(cfg.HotspotJSON) != "" {
+					path = cfg.HotspotJSON
+				}
+				modePlan.Description += fmt.Sprintf("; write %s to %s", format, path)
+			}
+			if cfg.SarifMerge != "" {
+				modePlan.Description += fmt.Sprintf("; merge into %s", cfg.SarifMerge)
+			}
+			if html := strings.TrimSpace(cfg.HotspotHTML); html != "" {
+				modePlan.Description += fmt.Sprintf("; write html to %s", html)
+			}
+			if err := window.validate(); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			}
+			if err := validateComplexityMode(cfg.HotspotComplexity); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			}
+			if err := validateGitBackend(cfg.GitBackend); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			}
+			if _, err := parseHotspotTiers(cfg.HotspotTiers); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			}
+			if selection, err := cfg.hotspotSelection(); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
 			} else {
-				modePlan.Description = "compute hotspots (no SARIF destination configured)"
+				modePlan.Description += fmt.Sprintf("; report %s", selection.describe())
+			}
+			if baseline := strings.TrimSpace(cfg.HotspotBaseline); baseline != "" {
+				modePlan.Description += fmt.Sprintf("; diff against baseline %s", baseline)
+			}
+			if gate, err := parseHotspotGate(cfg.HotspotFailOn); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			} else if gate != nil {
+				modePlan.Description += fmt.Sprintf("; fail with exit code %d on %s", exitCodeHotspotGate, gate.Spec)
+			}
+		case "coupling":
+			window := cfg.churnWindow()
+			minSupport, minConfidence, maxFiles, err := cfg.couplingThresholds()
+			if err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
+			}
+			if err := window.validate(); err != nil {
+				plan.Warnings = append(plan.Warnings, err.Error())
 			}
+			modePlan.Description = fmt.Sprintf("mine co-changing files (%s, support>=%d, confidence>=%.2f, commits<=%d files) and write to %s",
+				window.describe(), minSupport, minConfidence, maxFiles, cfg.CouplingOut)
 		case "diagnose-airgap":
 			modePlan.Command = []string{"punchtrunk", "--mode", "diagnose-airgap"}
 			modePlan.Description = "emit JSON diagnostics about offline readiness"
@@ -1061,10 +1426,244 @@ func detectCompetingToolConfigs(mode string) []string {
 }
 
 type Hotspot struct {
-	File       string
-	Churn      int
-	Complexity float64
-	Score      float64
+	File       string  `json:"file"`
+	Churn      int     `json:"churn"`
+	Complexity float64 `json:"complexity"`
+	Score      float64 `json:"score"`
+	// DecayedChurn is the recency-weighted churn used for scoring when the
+	// decay churn model is selected; zero under the linear model.
+	DecayedChurn float64 `json:"decayed_churn,omitempty"`
+	// Cognitive and Functions come from the language-aware estimator; Go
+	// files report per-function cyclomatic and cognitive complexity.
+	Cognitive float64              `json:"cognitive_complexity,omitempty"`
+	Functions []functionComplexity `json:"functions,omitempty"`
+	// Regions lists functions or line ranges holding the file's recent churn,
+	// hottest first. Only populated for the top --hotspot-regions results.
+	Regions []hotspotRegion `json:"regions,omitempty"`
+	// ComplexityZ is the complexity z-score used in the score; Rank is the
+	// 1-based position after sorting and Percentile places it among every
+	// ranked file; Changed marks files in the current diff.
+	ComplexityZ float64 `json:"complexity_z"`
+	Rank        int     `json:"rank"`
+	Percentile  float64 `json:"percentile"`
+	Changed     bool    `json:"changed"`
+	// Trend compares the hotspot with --hotspot-baseline; nil without one.
+	Trend *hotspotTrend `json:"trend,omitempty"`
+	// Ownership holds author spread and CODEOWNERS data for the file.
+	Ownership *fileOwnership `json:"ownership,omitempty"`
+}
+
+// hotspotReport carries ranked hotspots together with the analysis settings
+// that produced them so writers can echo them back to consumers.
+type hotspotReport struct {
+	Hotspots       []Hotspot
+	Window         churnWindow
+	Model          churnModel
+	ComplexityMode string
+	Filters        map[string]any
+	Tiers          hotspotTiers
+	// Trend is set when --hotspot-baseline loaded a previous report.
+	Trend *trendSummary
+	// Codeowners is the CODEOWNERS file used for ownership, if any.
+	Codeowners string
+	// Rollups aggregate every ranked file by directory, module and prefix.
+	Rollups hotspotRollups
+	// Selection records the limit and thresholds applied to Hotspots.
+	Selection *hotspotSelection
+	// ranked is the full ranking before selection; the gate checks it so
+	// --hotspot-limit, the thresholds and scope cannot hide a violation.
+	ranked []Hotspot
+}
+
+// rankedHotspots returns every ranked file, falling back to Hotspots for
+// reports built without a separate ranking.
+func (r *hotspotReport) rankedHotspots() []Hotspot {
+	if r.ranked != nil {
+		return r.ranked
+	}
+	return r.Hotspots
+}
+
+const (
+	churnModelLinear       = "linear"
+	churnModelDecay        = "decay"
+	defaultHotspotHalfLife = "30d"
+)
+
+// churnModel selects how per-commit churn is folded into a file's score.
+// Linear sums raw line counts; decay weights each commit by
+// 0.5^(age/HalfLife) so recent instability ranks higher.
+type churnModel struct {
+	Name     string
+	HalfLife time.Duration
+}
+
+func (cfg *Config) churnModel() (churnModel, error) {
+	name := churnModelLinear
+	halfLife := defaultHotspotHalfLife
+	if cfg != nil {
+		if v := strings.TrimSpace(strings.ToLower(cfg.HotspotChurnModel)); v != "" {
+			name = v
+		}
+		if v := strings.TrimSpace(cfg.HotspotHalfLife); v != "" {
+			halfLife = v
+		}
+	}
+	switch name {
+	case churnModelLinear:
+		return churnModel{Name: name}, nil
+	case churnModelDecay:
+		d, ok := parseHistoryDuration(halfLife)
+		if !ok {
+			return churnModel{}, fmt.Errorf("invalid hotspot-half-life %q: use a positive duration such as 30d, 2w or 720h", halfLife)
+		}
+		return churnModel{Name: name, HalfLife: d}, nil
+	default:
+		return churnModel{}, fmt.Errorf("unsupported hotspot-churn-model %q (expected linear or decay)", name)
+	}
+}
+
+func (m churnModel) describe() string {
+	if m.Name == churnModelDecay {
+		return fmt.Sprintf("decay churn model, half-life %s", m.HalfLife)
+	}
+	return "linear churn model"
+}
+
+func (m churnModel) properties() map[string]any {
+	props := map[string]any{"name": m.Name}
+	if m.Name == churnModelDecay {
+		props["half_life_hours"] = m.HalfLife.Hours()
+	}
+	return props
+}
+
+const defaultHotspotSince = "90 days"
+
+// churnWindow bounds the git history scanned for hotspot churn. Since and
+// Until accept git dates, durations (14d, 2w, 336h) or commit refs.
+type churnWindow struct {
+	Since      string
+	Until      string
+	MaxCommits int
+}
+
+func (cfg *Config) churnWindow() churnWindow {
+	w := churnWindow{Since: defaultHotspotSince}
+	if cfg == nil {
+		return w
+	}
+	if since := strings.TrimSpace(cfg.HotspotSince); since != "" {
+		w.Since = since
+	}
+	w.Until = strings.TrimSpace(cfg.HotspotUntil)
+	w.MaxCommits = cfg.HotspotMaxCommits
+	return w
+}
+
+func (w churnWindow) validate() error {
+	if w.MaxCommits < 0 {
+		return fmt.Errorf("hotspot-max-commits must be >= 0, got %d", w.MaxCommits)
+	}
+	return nil
+}
+
+func (w churnWindow) describe() string {
+	parts := []string{fmt.Sprintf("since %s", w.Since)}
+	if w.Until != "" {
+		parts = append(parts, fmt.Sprintf("until %s", w.Until))
+	}
+	if w.MaxCommits > 0 {
+		parts = append(parts, fmt.Sprintf("max %d commits", w.MaxCommits))
+	}
+	return "churn " + strings.Join(parts, ", ")
+}
+
+func (w churnWindow) properties() map[string]any {
+	return map[string]any{
+		"since":       w.Since,
+		"until":       w.Until,
+		"max_commits": w.MaxCommits,
+	}
+}
+
+// gitLogArgs translates the window into git log arguments. Bounds that resolve
+// to commits become a revision range; everything else is handed to git's
+// --since/--until date parser.
+func (w churnWindow) gitLogArgs(ctx context.Context) []string {
+	var args []string
+	var rangeStart, rangeEnd string
+	if w.Since != "" {
+		if date, ok := historyBoundDate(w.Since); ok {
+			args = append(args, "--since="+date)
+		} else if gitResolvesCommit(ctx, w.Since) {
+			rangeStart = w.Since
+		} else {
+			args = append(args, "--since="+w.Since)
+		}
+	}
+	if w.Until != "" {
+		if date, ok := historyBoundDate(w.Until); ok {
+			args = append(args, "--until="+date)
+		} else if gitResolvesCommit(ctx, w.Until) {
+			rangeEnd = w.Until
+		} else {
+			args = append(args, "--until="+w.Until)
+		}
+	}
+	if w.MaxCommits > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", w.MaxCommits))
+	}
+	switch {
+	case rangeStart != "" && rangeEnd != "":
+		args = append(args, rangeStart+".."+rangeEnd)
+	case rangeStart != "":
+		args = append(args, rangeStart+"..HEAD")
+	case rangeEnd != "":
+		args = append(args, rangeEnd)
+	}
+	return args
+}
+
+// historyBoundDate converts duration-style bounds (336h, 14d, 2w) into an
+// absolute RFC3339 timestamp relative to now.
+func historyBoundDate(value string) (string, bool) {
+	d, ok := parseHistoryDuration(value)
+	if !ok {
+		return "", false
+	}
+	return time.Now().Add(-d).UTC().Format(time.RFC3339), true
+}
+
+func parseHistoryDuration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, true
+	}
+	unit := value[len(value)-1]
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	switch unit {
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, true
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+func gitResolvesCommit(ctx context.Context, ref string) bool {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return false
+	}
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return cmd.Run() == nil
 }
 
 const (
@@ -1504,7 +2103,7 @@ func splitToolReference(ref string) (string, string) {
 
 func ensureEnvironment(ctx context.Context, cfg *Config) error {
 	if _, err := exec.LookPath("git"); err != nil {
-		return fmt.Errorf("git is required: %w", err)
+		cfg.log().Warnf("git not found in PATH; hotspots read history with the built-in go-git backend and skip blame-based regions")
 	}
 
 	if _, err := cfg.resolveTmpDir(); err != nil {
@@ -1898,9 +2497,9 @@ func checkGitExecutable() DiagnoseCheck {
 	if err != nil {
 		return DiagnoseCheck{
 			Name:           "git",
-			Status:         diagnoseStatusError,
-			Message:        "git executable not found in PATH",
-			Recommendation: "Install git and ensure it is available to PunchTrunk.",
+			Status:         diagnoseStatusWarn,
+			Message:        "git executable not found in PATH; hotspots fall back to the built-in go-git backend",
+			Recommendation: "Install git for trunk and for hotspot region attribution.",
 		}
 	}
 	return DiagnoseCheck{
@@ -2264,31 +2863,100 @@ func trunkExecutableName() string {
 }
 
 func computeHotspots(ctx context.Context, cfg *Config) ([]Hotspot, error) {
+	report, err := analyzeHotspots(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return report.Hotspots, nil
+}
+
+func analyzeHotspots(ctx context.Context, cfg *Config) (*hotspotReport, error) {
+	window := cfg.churnWindow()
+	if err := window.validate(); err != nil {
+		return nil, err
+	}
+	model, err := cfg.churnModel()
+	if err != nil {
+		return nil, err
+	}
+	complexityMode := complexityModeAuto
+	if cfg != nil && cfg.HotspotComplexity != "" {
+		complexityMode = cfg.HotspotComplexity
+	}
+	if err := validateComplexityMode(complexityMode); err != nil {
+		return nil, err
+	}
+	var tiers hotspotTiers
+	if cfg != nil {
+		if tiers, err = parseHotspotTiers(cfg.HotspotTiers); err != nil {
+			return nil, err
+		}
+	}
+	selection, err := cfg.hotspotSelection()
+	if err != nil {
+		return nil, err
+	}
+	baseline, haveBaseline, err := loadBaselineForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	history, err := cfg.history()
+	if err != nil {
+		return nil, err
+	}
+	phaseStart := time.Now()
 	changed := map[string]bool{}
-	if m, degraded, err := gitChangedFiles(ctx, cfg); err != nil {
+	if m, degraded, err := history.changedFiles(ctx); err != nil {
+		if selection.changedOnly() {
+			return nil, fmt.Errorf("hotspot-scope=changed: unable to resolve changed files: %w", err)
+		}
 		if cfg != nil && cfg.Verbose {
 			cfg.log().Warnf("unable to resolve changed files: %v", err)
 		}
 	} else {
 		changed = m
-		if degraded && cfg != nil && cfg.Verbose {
+		switch {
+		case degraded && selection.changedOnly():
+			cfg.log().Warnf("base branch %q not found; hotspot-scope=changed is using the last commit only", cfg.BaseBranch)
+		case degraded && cfg != nil && cfg.Verbose:
 			cfg.log().Infof("falling back to limited git history for changed files; diff weighting may be incomplete")
 		}
 	}
 	// Consider changed files as primary focus; also consider top churn files overall.
-	churn, degradedChurn, err := gitChurn(ctx, "90 days")
+	commits, degradedChurn, err := history.log(ctx, window, logNumstat)
 	if err != nil {
 		return nil, err
 	}
+	churn := churnTotals(commits)
+	filter := newHotspotFilter(cfg)
+	skipped := filter.apply(ctx, churn, binaryPaths(commits))
+	if len(skipped) > 0 && cfg != nil {
+		fields := LogFields{}
+		for reason, count := range skipped {
+			fields[reason] = count
+		}
+		cfg.log().Event("info", "hotspots.filtered", fields)
+	}
+	logPhase(cfg, phaseGitHistory, phaseStart, LogFields{"backend": history.name(), "commits": len(commits), "files": len(churn)})
+	var decayed map[string]float64
+	if model.Name == churnModelDecay {
+		decayed = decayedChurn(commits, model.HalfLife, time.Now())
+	}
 	if degradedChurn && cfg != nil && cfg.Verbose {
 		cfg.log().Infof("falling back to limited git history for churn; hotspot rankings may be partial")
 	}
-	// Simple complexity proxy: token density
-	comp := map[string]float64{}
-	for f := range churn {
-		c, _ := roughComplexity(f)
-		comp[f] = c
+	phaseStart = time.Now()
+	details, err := computeComplexity(ctx, cfg, churn, complexityMode)
+	if err != nil {
+		return nil, err
 	}
+	comp := make(map[string]float64, len(details))
+	for f, fc := range details {
+		comp[f] = fc.Total
+	}
+	cfg.hotspotCache().flush(cfg)
+	logPhase(cfg, phaseComplexity, phaseStart, LogFields{"files": len(details), "workers": cfg.workerCount(len(details))})
+	phaseStart = time.Now()
 	// Score and rank
 	var hs []Hotspot
 	// z-score complexity
@@ -2304,30 +2972,90 @@ func computeHotspots(ctx context.Context, cfg *Config) ([]Hotspot, error) {
 		if std > 0 {
 			cz = (comp[f] - mean) / std
 		}
-		score := math.Log1p(float64(ch)) * (1.0 + cz)
+		churnSignal := float64(ch)
+		if decayed != nil {
+			churnSignal = decayed[f]
+		}
+		score := math.Log1p(churnSignal) * (1.0 + cz)
 		// Prioritise changed files slightly
 		if changed[f] {
 			score *= 1.15
 		}
-		hs = append(hs, Hotspot{File: f, Churn: ch, Complexity: comp[f], Score: score})
-	}
-	sort.Slice(hs, func(i, j int) bool { return hs[i].Score > hs[j].Score })
-	// Limit to reasonable number for dashboards
-	if len(hs) > 500 {
-		hs = hs[:500]
+		hs = append(hs, Hotspot{
+			File:         f,
+			Churn:        ch,
+			Complexity:   comp[f],
+			Score:        score,
+			DecayedChurn: decayed[f],
+			Cognitive:    details[f].Cognitive,
+			Functions:    details[f].Functions,
+			ComplexityZ:  cz,
+			Changed:      changed[f],
+		})
 	}
-	return hs, nil
+	sort.Slice(hs, func(i, j int) bool {
+		if hs[i].Score != hs[j].Score {
+			return hs[i].Score > hs[j].Score
+		}
+		return hs[i].File < hs[j].File
+	})
+	for i := range hs {
+		hs[i].Rank = i + 1
+		hs[i].Percentile = hotspotPercentile(i+1, len(hs))
+	}
+	var trend *trendSummary
+	if haveBaseline {
+		trend = applyHotspotBaseline(hs, baseline, cfg.HotspotBaseline)
+		cfg.log().Event("info", "hotspots.trend", trend.logFields())
+	}
+	all := hs
+	ranked := len(hs)
+	rollups := computeRollups(hs, cfg.rollupDepth(), cfg.rollupPrefixes())
+	hs = selection.apply(hs)
+	cfg.log().Event("info", "hotspots.selected", selection.logFields())
+	owners := loadCodeowners()
+	attributeOwnership(hs, commits, owners, ranked)
+	codeownersPath := ""
+	if owners != nil {
+		codeownersPath = owners.Path
+	}
+	logPhase(cfg, phaseScoring, phaseStart, LogFields{"ranked": ranked, "reported": len(hs)})
+	if cfg != nil && cfg.HotspotRegionLimit > 0 {
+		phaseStart = time.Now()
+		attributeHotspotRegions(ctx, cfg, hs, commits)
+		logPhase(cfg, phaseRegions, phaseStart, LogFields{"limit": cfg.HotspotRegionLimit})
+	}
+	return &hotspotReport{
+		Hotspots:       hs,
+		Window:         window,
+		Model:          model,
+		ComplexityMode: complexityMode,
+		Filters:        filter.properties(skipped),
+		Tiers:          tiers,
+		Trend:          trend,
+		Codeowners:     codeownersPath,
+		Rollups:        rollups,
+		Selection:      selection,
+		ranked:         all,
+	}, nil
 }
 
 func gitChangedFiles(ctx context.Context, cfg *Config) (map[string]bool, bool, error) {
+	base := ""
+	if cfg != nil {
+		base = cfg.BaseBranch
+	}
+	return gitChangedFilesSince(ctx, cfg, base)
+}
+
+// gitChangedFilesSince lists files changed in base...HEAD, falling back to the
+// last commit (degraded) when base cannot be resolved.
+func gitChangedFilesSince(ctx context.Context, cfg *Config, base string) (map[string]bool, bool, error) {
 	type attempt struct {
 		desc string
 		args []string
 	}
-	base := ""
-	if cfg != nil {
-		base = strings.TrimSpace(cfg.BaseBranch)
-	}
+	base = strings.TrimSpace(base)
 	var attempts []attempt
 	if base != "" {
 		attempts = append(attempts, attempt{
@@ -2368,51 +3096,111 @@ func gitChangedFiles(ctx context.Context, cfg *Config) (map[string]bool, bool, e
 	return map[string]bool{}, degraded, nil
 }
 
-func gitChurn(ctx context.Context, since string) (map[string]int, bool, error) {
+// numstatCommitMarker prefixes the per-commit header line emitted by
+// numstatFormat so commit timestamps can be told apart from numstat rows.
+const numstatCommitMarker = "\x1e"
+
+// numstatFormat emits "sha unix-time author-email"; %aE honours .mailmap.
+const numstatFormat = "--format=tformat:%x1e%H %ct %aE"
+
+type numstatCommit struct {
+	SHA    string
+	Time   time.Time
+	Author string
+	Files  []numstatFile
+}
+
+type numstatFile struct {
+	Path string `json:"path"`
+	// OldPath is set when git reported the entry as a rename.
+	OldPath string `json:"old_path,omitempty"`
+	Added   int    `json:"added,omitempty"`
+	Deleted int    `json:"deleted,omitempty"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// Lines returns the churn contributed by this entry; binary changes count as 1.
+func (f numstatFile) Lines() int {
+	if f.Binary {
+		return 1
+	}
+	return f.Added + f.Deleted
+}
+
+func gitChurn(ctx context.Context, window churnWindow) ([]numstatCommit, bool, error) {
+	commits, degraded, err := gitLogCommits(ctx, window, parseNumstatCommits, "--numstat", "--find-renames")
+	return foldRenames(commits), degraded, err
+}
+
+// gitLogCommits runs `git log <statFlags>` over the churn window and parses
+// the output with parse. When the window cannot be resolved (shallow clones,
+// unknown refs) it retries over HEAD; repositories without history yield no
+// commits and degraded=true rather than an error.
+func gitLogCommits(ctx context.Context, window churnWindow, parse func(string) []numstatCommit, statFlags ...string) ([]numstatCommit, bool, error) {
+	windowArgs := window.gitLogArgs(ctx)
+	base := append(append([]string{"log"}, statFlags...), numstatFormat)
+	fallbackArgs := append([]string(nil), base...)
+	if window.MaxCommits > 0 {
+		fallbackArgs = append(fallbackArgs, fmt.Sprintf("--max-count=%d", window.MaxCommits))
+	}
+	stat := strings.Join(statFlags, " ")
 	attempts := []struct {
 		desc string
 		args []string
 	}{
 		{
-			desc: fmt.Sprintf("git log --since=%s --numstat", since),
-			args: []string{"log", fmt.Sprintf("--since=%s", since), "--numstat", "--format=tformat:"},
+			desc: fmt.Sprintf("git log %s %s", strings.Join(windowArgs, " "), stat),
+			args: append(append([]string(nil), base...), windowArgs...),
 		},
 		{
-			desc: "git log --numstat HEAD",
-			args: []string{"log", "--numstat", "--format=tformat:", "HEAD"},
+			desc: fmt.Sprintf("git log %s HEAD", stat),
+			args: append(fallbackArgs, "HEAD"),
 		},
 	}
 	var lastErr error
 	var lastStderr string
 	for idx, att := range attempts {
-		churn, stderr, err := runGitNumstat(ctx, att.args...)
+		output, stderr, err := runGitLog(ctx, att.args...)
 		if err == nil {
-			return churn, idx > 0, nil
+			return parse(output), idx > 0, nil
 		}
 		lastErr = err
 		lastStderr = stderr
 		if isNoHistory(stderr) {
-			return map[string]int{}, true, nil
+			return nil, true, nil
 		}
 	}
 	if lastErr != nil {
 		if isNoHistory(lastStderr) {
-			return map[string]int{}, true, nil
+			return nil, true, nil
 		}
-		return map[string]int{}, true, fmt.Errorf("git log failed: %w", lastErr)
+		return nil, true, fmt.Errorf("git log failed: %w", lastErr)
+	}
+	return nil, false, nil
+}
+
+func runGitLog(ctx context.Context, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", stderr.String(), err
 	}
-	return map[string]int{}, false, nil
+	return stdout.String(), "", nil
 }
 
-func runGitNumstat(ctx context.Context, args ...string) (map[string]int, string, error) {
+// runGitLogInput is runGitLog with stdin, for --stdin revision lists.
+func runGitLogInput(ctx context.Context, stdin string, args ...string) (string, string, error) {
 	var stdout, stderr bytes.Buffer
 	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = strings.NewReader(stdin)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return nil, stderr.String(), err
+		return "", stderr.String(), err
 	}
-	return parseNumstat(stdout.String()), "", nil
+	return stdout.String(), "", nil
 }
 
 func parseNameOnly(output string) map[string]bool {
@@ -2427,20 +3215,145 @@ func parseNameOnly(output string) map[string]bool {
 }
 
 func parseNumstat(output string) map[string]int {
-	churn := map[string]int{}
+	return churnTotals(parseNumstatCommits(output))
+}
+
+// parseNumstatCommits reads `git log --numstat` output. Commit headers written
+// by numstatFormat start a new commit; output without headers (for example
+// --format=tformat:) is collected into a single undated commit.
+func parseNumstatCommits(output string) []numstatCommit {
+	var commits []numstatCommit
+	current := -1
 	for _, line := range strings.Split(output, "\n") {
-		fields := strings.Fields(line)
-		if len(fields) == 3 {
-			added := fields[0]
-			deleted := fields[1]
-			file := fields[2]
-			if added == "-" || deleted == "-" {
-				churn[file] += 1
-				continue
+		if strings.HasPrefix(line, numstatCommitMarker) {
+			commits = append(commits, parseNumstatHeader(strings.TrimPrefix(line, numstatCommitMarker)))
+			current = len(commits) - 1
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || strings.TrimSpace(fields[2]) == "" {
+			continue
+		}
+		fields[0] = strings.TrimSpace(fields[0])
+		fields[1] = strings.TrimSpace(fields[1])
+		entry := numstatFile{}
+		entry.OldPath, entry.Path = parseNumstatPath(fields[2])
+		if fields[0] == "-" || fields[1] == "-" {
+			entry.Binary = true
+		} else {
+			entry.Added = atoiSafe(fields[0])
+			entry.Deleted = atoiSafe(fields[1])
+		}
+		if current < 0 {
+			commits = append(commits, numstatCommit{})
+			current = 0
+		}
+		commits[current].Files = append(commits[current].Files, entry)
+	}
+	return commits
+}
+
+// parseNumstatPath splits numstat rename notation into old and new paths:
+// "old.go => new.go" and "pkg/{a => b}/file.go". Plain paths return an empty
+// old path.
+func parseNumstatPath(raw string) (string, string) {
+	if !strings.Contains(raw, " => ") {
+		return "", raw
+	}
+	open := strings.Index(raw, "{")
+	closing := strings.LastIndex(raw, "}")
+	if open >= 0 && closing > open && strings.Contains(raw[open:closing], " => ") {
+		prefix, suffix := raw[:open], raw[closing+1:]
+		parts := strings.SplitN(raw[open+1:closing], " => ", 2)
+		join := func(mid string) string {
+			return strings.ReplaceAll(prefix+mid+suffix, "//", "/")
+		}
+		return strings.TrimPrefix(join(parts[0]), "/"), strings.TrimPrefix(join(parts[1]), "/")
+	}
+	parts := strings.SplitN(raw, " => ", 2)
+	return parts[0], parts[1]
+}
+
+// foldRenames rewrites historical paths to each file's current name. Commits
+// arrive newest first, so a rename seen at commit N redirects the old path in
+// every older commit to wherever the file lives today.
+func foldRenames(commits []numstatCommit) []numstatCommit {
+	alias := map[string]string{}
+	resolve := func(p string) string {
+		if current, ok := alias[p]; ok {
+			return current
+		}
+		return p
+	}
+	for i := range commits {
+		for j := range commits[i].Files {
+			f := &commits[i].Files[j]
+			current := resolve(f.Path)
+			f.Path = current
+			if f.OldPath != "" && f.OldPath != current {
+				alias[f.OldPath] = current
 			}
-			a := atoiSafe(added)
-			d := atoiSafe(deleted)
-			churn[file] += a + d
+		}
+	}
+	return commits
+}
+
+// parseNumstatHeader reads "<sha> <unix-ts> [author]" (or a bare timestamp).
+func parseNumstatHeader(header string) numstatCommit {
+	fields := strings.Fields(header)
+	var c numstatCommit
+	if len(fields) == 0 {
+		return c
+	}
+	tsField := fields[0]
+	if len(fields) > 1 {
+		c.SHA = fields[0]
+		tsField = fields[1]
+	}
+	if len(fields) > 2 {
+		c.Author = strings.ToLower(fields[2])
+	}
+	if ts, err := strconv.ParseInt(tsField, 10, 64); err == nil {
+		c.Time = time.Unix(ts, 0)
+	}
+	return c
+}
+
+func binaryPaths(commits []numstatCommit) map[string]bool {
+	binary := map[string]bool{}
+	for _, c := range commits {
+		for _, f := range c.Files {
+			if f.Binary {
+				binary[f.Path] = true
+			}
+		}
+	}
+	return binary
+}
+
+func churnTotals(commits []numstatCommit) map[string]int {
+	churn := map[string]int{}
+	for _, c := range commits {
+		for _, f := range c.Files {
+			churn[f.Path] += f.Lines()
+		}
+	}
+	return churn
+}
+
+// decayedChurn weights each commit's churn by 0.5^(age/halfLife). Commits
+// dated in the future (clock skew) count at full weight.
+func decayedChurn(commits []numstatCommit, halfLife time.Duration, now time.Time) map[string]float64 {
+	churn := map[string]float64{}
+	for _, c := range commits {
+		weight := 1.0
+		if halfLife > 0 && !c.Time.IsZero() {
+			if age := now.Sub(c.Time); age > 0 {
+				weight = math.Exp2(-float64(age) / float64(halfLife))
+			}
+		}
+		for _, f := range c.Files {
+			churn[f.Path] += float64(f.Lines()) * weight
 		}
 	}
 	return churn
@@ -2460,18 +3373,16 @@ func atoiSafe(s string) int {
 	return v
 }
 
-func roughComplexity(path string) (float64, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return 0, err
-	}
+// tokenDensity is the legacy tokens-per-line proxy, still selectable via
+// --hotspot-complexity=density.
+func tokenDensity(data []byte) float64 {
 	content := string(data)
 	lines := strings.Count(content, "\n") + 1
 	tokens := len(strings.Fields(content))
 	if lines == 0 {
-		return 0, nil
+		return 0
 	}
-	return float64(tokens) / float64(lines), nil
+	return float64(tokens) / float64(lines)
 }
 
 func meanStd(vals []float64) (float64, float64) {
@@ -2506,68 +3417,258 @@ type SarifLog struct {
 	Runs    []SarifRun `json:"runs"`
 }
 type SarifRun struct {
-	Tool    SarifTool     `json:"tool"`
-	Results []SarifResult `json:"results"`
+	Tool               SarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]SarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []SarifResult                    `json:"results"`
+	Properties         map[string]any                   `json:"properties,omitempty"`
 }
 type SarifTool struct {
 	Driver SarifDriver `json:"driver"`
 }
 type SarifDriver struct {
-	Name           string `json:"name"`
-	Version        string `json:"version,omitempty"`
-	InformationURI string `json:"informationUri,omitempty"`
+	Name           string                     `json:"name"`
+	Version        string                     `json:"version,omitempty"`
+	InformationURI string                     `json:"informationUri,omitempty"`
+	Rules          []SarifReportingDescriptor `json:"rules,omitempty"`
+}
+type SarifReportingDescriptor struct {
+	ID                   string                   `json:"id"`
+	Name                 string                   `json:"name,omitempty"`
+	ShortDescription     *SarifMessage            `json:"shortDescription,omitempty"`
+	FullDescription      *SarifMessage            `json:"fullDescription,omitempty"`
+	Help                 *SarifMultiformatMessage `json:"help,omitempty"`
+	HelpURI              string                   `json:"helpUri,omitempty"`
+	DefaultConfiguration *SarifRuleConfiguration  `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]any           `json:"properties,omitempty"`
+}
+type SarifMultiformatMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+type SarifRuleConfiguration struct {
+	Level string `json:"level"`
 }
 type SarifResult struct {
-	RuleID    string          `json:"ruleId"`
-	Level     string          `json:"level"`
-	Message   SarifMessage    `json:"message"`
-	Locations []SarifLocation `json:"locations,omitempty"`
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           *int              `json:"ruleIndex,omitempty"`
+	Level               string            `json:"level"`
+	Message             SarifMessage      `json:"message"`
+	Locations           []SarifLocation   `json:"locations,omitempty"`
+	RelatedLocations    []SarifLocation   `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	BaselineState       string            `json:"baselineState,omitempty"`
+	Properties          map[string]any    `json:"properties,omitempty"`
 }
 type SarifMessage struct {
 	Text string `json:"text"`
 }
 type SarifLocation struct {
-	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+	ID               int                    `json:"id,omitempty"`
+	PhysicalLocation SarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []SarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *SarifMessage          `json:"message,omitempty"`
 }
 type SarifPhysicalLocation struct {
 	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+type SarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
 }
 type SarifArtifactLocation struct {
-	URI string `json:"uri"`
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
 }
 
 func writeSARIF(path string, hs []Hotspot) error {
+	return writeHotspotSARIF(path, &hotspotReport{Hotspots: hs})
+}
+
+const (
+	hotspotRuleID          = "hotspot"
+	hotspotHelpURI         = "https://github.com/IAmJonoBo/PunchTrunk/blob/main/docs/hotspots-methodology.md"
+	hotspotFingerprintKey  = "punchtrunkHotspot/v1"
+	sarifSchemaURI         = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.5.json"
+	hotspotRuleDescription = "Files that combine high recent git churn with high complexity. Changes here are the most likely to introduce defects."
+)
+
+func hotspotRule() SarifReportingDescriptor {
+	return SarifReportingDescriptor{
+		ID:               hotspotRuleID,
+		Name:             "Hotspot",
+		ShortDescription: &SarifMessage{Text: "Frequently changed, complex file"},
+		FullDescription:  &SarifMessage{Text: hotspotRuleDescription},
+		Help: &SarifMultiformatMessage{
+			Text: "Prioritise review, tests and refactoring for this file. Score = log1p(churn) * (1 + complexity z-score), boosted 15% when the file is part of the current change.",
+			Markdown: "Prioritise review, tests and refactoring for this file.\n\n" +
+				"`score = log1p(churn) * (1 + complexity_z)`, boosted 15% when the file is part of the current change. " +
+				"Result properties carry `churn`, `complexity`, `complexity_z`, `score`, `rank` and `changed`. " +
+				"See [Hotspots Methodology](" + hotspotHelpURI + ").",
+		},
+		HelpURI:              hotspotHelpURI,
+		DefaultConfiguration: &SarifRuleConfiguration{Level: "note"},
+		Properties: map[string]any{
+			"tags": []string{"maintainability", "hotspot"},
+		},
+	}
+}
+
+func hotspotProperties(h Hotspot, tiers hotspotTiers) map[string]any {
+	props := map[string]any{
+		"churn":        h.Churn,
+		"complexity":   h.Complexity,
+		"complexity_z": h.ComplexityZ,
+		"score":        h.Score,
+		"rank":         h.Rank,
+		"changed":      h.Changed,
+	}
+	if h.Percentile > 0 {
+		props["percentile"] = math.Round(h.Percentile*100) / 100
+	}
+	if len(tiers) > 0 {
+		props["tier"] = tiers.level(h)
+	}
+	if h.Ownership != nil {
+		for k, v := range h.Ownership.properties() {
+			props[k] = v
+		}
+	}
+	if h.Trend != nil {
+		props["trend"] = h.Trend.State
+		if h.Trend.State != trendNew {
+			props["score_delta"] = math.Round(h.Trend.ScoreDelta*1000) / 1000
+			props["rank_delta"] = h.Trend.RankDelta
+			props["baseline_rank"] = h.Trend.BaselineRank
+		}
+	}
+	if h.DecayedChurn > 0 {
+		props["decayed_churn"] = h.DecayedChurn
+	}
+	if h.Cognitive > 0 {
+		props["cognitive_complexity"] = h.Cognitive
+	}
+	return props
+}
+
+// hotspotFingerprint keys a hotspot on its path so code scanning can follow
+// the same file across runs even as its rank, score or hottest region move.
+func hotspotFingerprint(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeHotspotSARIF(path string, report *hotspotReport) error {
+	log := buildHotspotSARIF(report)
+	return writeIndentedJSON(path, &log)
+}
+
+func writeIndentedJSON(path string, v any) error {
+	tmp := &bytes.Buffer{}
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return os.WriteFile(path, tmp.Bytes(), 0o644)
+}
+
+func buildHotspotSARIF(report *hotspotReport) SarifLog {
+	if report == nil {
+		report = &hotspotReport{}
+	}
+	hs := report.Hotspots
 	log := SarifLog{
 		Version: "2.1.0",
-		Schema:  "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.5.json",
+		Schema:  sarifSchemaURI,
 		Runs: []SarifRun{{
 			Tool: SarifTool{Driver: SarifDriver{
 				Name:           "PunchTrunk",
+				Version:        Version,
 				InformationURI: "https://docs.trunk.io/",
+				Rules:          []SarifReportingDescriptor{hotspotRule()},
 			}},
 		}},
 	}
-	for _, h := range hs {
+	if report.Window.Since != "" {
+		log.Runs[0].Properties = map[string]any{
+			"churn_window": report.Window.properties(),
+		}
+		if report.Model.Name != "" {
+			log.Runs[0].Properties["churn_model"] = report.Model.properties()
+		}
+		if report.ComplexityMode != "" {
+			log.Runs[0].Properties["complexity_model"] = report.ComplexityMode
+		}
+		if len(report.Filters) > 0 {
+			log.Runs[0].Properties["filters"] = report.Filters
+		}
+		if len(report.Tiers) > 0 {
+			log.Runs[0].Properties["severity_tiers"] = report.Tiers.properties()
+		}
+		if report.Codeowners != "" {
+			log.Runs[0].Properties["codeowners"] = report.Codeowners
+		}
+		if report.Selection != nil {
+			log.Runs[0].Properties["selection"] = report.Selection
+		}
+		if report.Selection.changedOnly() {
+			log.Runs[0].Properties["summary"] = report.Selection.touchedSummary()
+		}
+	}
+	if report.Trend != nil {
+		if log.Runs[0].Properties == nil {
+			log.Runs[0].Properties = map[string]any{}
+		}
+		log.Runs[0].Properties["trend"] = report.Trend
+	}
+	for idx, h := range hs {
 		msg := fmt.Sprintf("Hotspot candidate: churn=%d, complexity=%.2f, score=%.2f", h.Churn, h.Complexity, h.Score)
-		log.Runs[0].Results = append(log.Runs[0].Results, SarifResult{
-			RuleID:  "hotspot",
-			Level:   "note",
-			Message: SarifMessage{Text: msg},
+		uri := filepath.ToSlash(h.File)
+		if h.Rank == 0 {
+			h.Rank = idx + 1
+		}
+		ruleIndex := 0
+		result := SarifResult{
+			RuleID:    hotspotRuleID,
+			RuleIndex: &ruleIndex,
+			Level:     report.Tiers.level(h),
+			Message:   SarifMessage{Text: msg},
 			Locations: []SarifLocation{{
 				PhysicalLocation: SarifPhysicalLocation{
-					ArtifactLocation: SarifArtifactLocation{URI: filepath.ToSlash(h.File)},
+					ArtifactLocation: SarifArtifactLocation{URI: uri},
 				},
 			}},
-		})
-	}
-	tmp := &bytes.Buffer{}
-	enc := json.NewEncoder(tmp)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(&log); err != nil {
-		return err
+			PartialFingerprints: map[string]string{hotspotFingerprintKey: hotspotFingerprint(uri)},
+			Properties:          hotspotProperties(h, report.Tiers),
+		}
+		if h.Trend != nil {
+			result.BaselineState = h.Trend.sarifBaselineState()
+		}
+		if report.Selection.changedOnly() {
+			msg = fmt.Sprintf("%s; repo-wide rank #%d of %d (percentile %.0f)", msg, h.Rank, report.Selection.Ranked, h.Percentile)
+			result.Message.Text = msg
+		}
+		if len(h.Regions) > 0 {
+			result.Message.Text = fmt.Sprintf("%s; hottest region: %s", msg, h.Regions[0].describe())
+			result.Locations[0] = h.Regions[0].sarifLocation(uri, 0)
+			for idx, region := range h.Regions[1:] {
+				result.RelatedLocations = append(result.RelatedLocations, region.sarifLocation(uri, idx+1))
+			}
+		}
+		log.Runs[0].Results = append(log.Runs[0].Results, result)
 	}
-	if err := os.WriteFile(path, tmp.Bytes(), 0o644); err != nil {
-		return err
+	if report.Trend != nil {
+		for _, r := range report.Trend.resolved {
+			log.Runs[0].Results = append(log.Runs[0].Results, r.sarifResult())
+		}
 	}
-	return nil
+	return log
 }
@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
@@ -833,6 +834,9 @@ func TestParseFlagsDefaultsUseEnv(t *testing.T) {
 	if cfg.Timeout != 900*time.Second {
 		t.Fatalf("expected default timeout of 900s, got %s", cfg.Timeout)
 	}
+	if !cfg.HotspotCache {
+		t.Fatalf("expected the hotspot cache enabled by default")
+	}
 }
 
 func TestParseFlagsOverrides(t *testing.T) {
@@ -1498,8 +1502,8 @@ func mustChdir(t *testing.T, dir string) string {
 	return prev
 }
 
-// TestRoughComplexity validates the complexity heuristic for various file types.
-func TestRoughComplexity(t *testing.T) {
+// TestDensityComplexity validates the token-density heuristic for various file types.
+func TestDensityComplexity(t *testing.T) {
 	tests := []struct {
 		name    string
 		content string
@@ -1540,10 +1544,11 @@ func TestRoughComplexity(t *testing.T) {
 				t.Fatalf("writeFile: %v", err)
 			}
 
-			complexity, err := roughComplexity(path)
+			fc, err := estimateComplexity(path, complexityModeDensity)
 			if err != nil {
-				t.Fatalf("roughComplexity: %v", err)
+				t.Fatalf("estimateComplexity: %v", err)
 			}
+			complexity := fc.Total
 
 			if complexity < tt.wantMin || complexity > tt.wantMax {
 				t.Errorf("complexity = %f, want between %f and %f", complexity, tt.wantMin, tt.wantMax)
@@ -1728,3 +1733,249 @@ func TestAtoiSafe(t *testing.T) {
 		})
 	}
 }
+
+func TestParseHistoryDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{in: "14d", want: 14 * 24 * time.Hour, ok: true},
+		{in: "2w", want: 14 * 24 * time.Hour, ok: true},
+		{in: "336h", want: 336 * time.Hour, ok: true},
+		{in: "90 days", ok: false},
+		{in: "2024-01-01", ok: false},
+		{in: "HEAD~5", ok: false},
+		{in: "0d", ok: false},
+		{in: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseHistoryDuration(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseHistoryDuration(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestChurnWindowGitLogArgs(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "a.go", "package a\n")
+	gitAddCommit(t, repo, "first")
+	writeFile(t, repo, "a.go", "package a\n\nvar X = 1\n")
+	gitAddCommit(t, repo, "second")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	ctx := context.Background()
+	args := churnWindow{Since: "HEAD~1", MaxCommits: 5}.gitLogArgs(ctx)
+	want := []string{"--max-count=5", "HEAD~1..HEAD"}
+	if !slices.Equal(args, want) {
+		t.Fatalf("ref window args = %v, want %v", args, want)
+	}
+	args = churnWindow{Since: "2 weeks ago", Until: "HEAD~1"}.gitLogArgs(ctx)
+	want = []string{"--since=2 weeks ago", "HEAD~1"}
+	if !slices.Equal(args, want) {
+		t.Fatalf("date window args = %v, want %v", args, want)
+	}
+	args = churnWindow{Since: "14d"}.gitLogArgs(ctx)
+	if len(args) != 1 || !strings.HasPrefix(args[0], "--since=") || strings.Contains(args[0], "14d") {
+		t.Fatalf("expected duration converted to absolute date, got %v", args)
+	}
+
+	commits, _, err := gitChurn(ctx, churnWindow{Since: "HEAD~1"})
+	if err != nil {
+		t.Fatalf("gitChurn: %v", err)
+	}
+	if churn := churnTotals(commits); churn["a.go"] != 2 {
+		t.Fatalf("expected churn limited to latest commit (2 lines), got %d", churn["a.go"])
+	}
+}
+
+func TestWriteHotspotSARIFRecordsWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sarif")
+	report := &hotspotReport{
+		Hotspots: []Hotspot{{File: "main.go", Churn: 3, Score: 1}},
+		Window:   churnWindow{Since: "14d", Until: "HEAD", MaxCommits: 100},
+	}
+	if err := writeHotspotSARIF(path, report); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	window, ok := log.Runs[0].Properties["churn_window"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected churn_window run property, got %v", log.Runs[0].Properties)
+	}
+	if window["since"] != "14d" || window["until"] != "HEAD" || window["max_commits"] != float64(100) {
+		t.Fatalf("unexpected churn_window: %v", window)
+	}
+}
+
+func TestWriteHotspotSARIFRuleMetadataAndProperties(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sarif")
+	report := &hotspotReport{Hotspots: []Hotspot{
+		{File: "a.go", Churn: 9, Complexity: 12, ComplexityZ: 1.5, Score: 5.4, Rank: 1, Changed: true},
+		{File: "b.go", Churn: 2, Complexity: 3, ComplexityZ: -0.5, Score: 0.5},
+	}}
+	if err := writeHotspotSARIF(path, report); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	rules := log.Runs[0].Tool.Driver.Rules
+	if len(rules) != 1 || rules[0].ID != "hotspot" || rules[0].HelpURI == "" || rules[0].FullDescription == nil {
+		t.Fatalf("expected hotspot rule metadata, got %+v", rules)
+	}
+	if rules[0].DefaultConfiguration == nil || rules[0].DefaultConfiguration.Level != "note" {
+		t.Fatalf("expected default level note, got %+v", rules[0].DefaultConfiguration)
+	}
+	first := log.Runs[0].Results[0]
+	if first.RuleIndex == nil || *first.RuleIndex != 0 {
+		t.Fatalf("expected ruleIndex 0, got %v", first.RuleIndex)
+	}
+	props := first.Properties
+	if props["churn"] != float64(9) || props["complexity_z"] != 1.5 || props["rank"] != float64(1) || props["changed"] != true {
+		t.Fatalf("unexpected result properties: %v", props)
+	}
+	if rank := log.Runs[0].Results[1].Properties["rank"]; rank != float64(2) {
+		t.Fatalf("expected rank to default to position, got %v", rank)
+	}
+	fp := first.PartialFingerprints[hotspotFingerprintKey]
+	if fp == "" || fp != hotspotFingerprint("a.go") || fp == log.Runs[0].Results[1].PartialFingerprints[hotspotFingerprintKey] {
+		t.Fatalf("expected stable per-file fingerprints, got %v", first.PartialFingerprints)
+	}
+}
+
+func TestParseNumstatCommits(t *testing.T) {
+	output := "\x1eabc123 1700000000\n\n3\t1\tmain.go\n-\t-\tlogo.png\n\x1e1690000000\n\n5\t0\tmain.go\n"
+	commits := parseNumstatCommits(output)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].SHA != "abc123" || commits[0].Time.Unix() != 1700000000 || len(commits[0].Files) != 2 {
+		t.Fatalf("unexpected first commit: %+v", commits[0])
+	}
+	if commits[1].SHA != "" || commits[1].Time.Unix() != 1690000000 {
+		t.Fatalf("expected bare timestamp header to parse: %+v", commits[1])
+	}
+	if !commits[0].Files[1].Binary {
+		t.Fatalf("expected binary entry for logo.png: %+v", commits[0].Files[1])
+	}
+	churn := churnTotals(commits)
+	if churn["main.go"] != 9 || churn["logo.png"] != 1 {
+		t.Fatalf("unexpected churn totals: %v", churn)
+	}
+	legacy := parseNumstat("2\t2\tutil.go\n")
+	if legacy["util.go"] != 4 {
+		t.Fatalf("expected header-less numstat to parse, got %v", legacy)
+	}
+}
+
+func TestDecayedChurnFavoursRecentCommits(t *testing.T) {
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	halfLife := 10 * 24 * time.Hour
+	commits := []numstatCommit{
+		{Time: now.Add(-24 * time.Hour), Files: []numstatFile{{Path: "recent.go", Added: 10}}},
+		{Time: now.Add(-89 * 24 * time.Hour), Files: []numstatFile{{Path: "old.go", Added: 100}}},
+		{Time: now.Add(-10 * 24 * time.Hour), Files: []numstatFile{{Path: "half.go", Added: 8}}},
+	}
+	decayed := decayedChurn(commits, halfLife, now)
+	if decayed["recent.go"] <= decayed["old.go"] {
+		t.Fatalf("expected recent churn to outweigh old refactor: %v", decayed)
+	}
+	if math.Abs(decayed["half.go"]-4) > 1e-9 {
+		t.Fatalf("expected one half-life to halve churn, got %f", decayed["half.go"])
+	}
+}
+
+func TestConfigChurnModel(t *testing.T) {
+	model, err := (&Config{}).churnModel()
+	if err != nil || model.Name != churnModelLinear {
+		t.Fatalf("expected linear default, got %+v, %v", model, err)
+	}
+	model, err = (&Config{HotspotChurnModel: "decay", HotspotHalfLife: "2w"}).churnModel()
+	if err != nil || model.HalfLife != 14*24*time.Hour {
+		t.Fatalf("expected decay with 2w half-life, got %+v, %v", model, err)
+	}
+	if _, err := (&Config{HotspotChurnModel: "decay", HotspotHalfLife: "soon"}).churnModel(); err == nil {
+		t.Fatalf("expected invalid half-life to error")
+	}
+	if _, err := (&Config{HotspotChurnModel: "quadratic"}).churnModel(); err == nil {
+		t.Fatalf("expected unknown model to error")
+	}
+}
+
+func TestParseNumstatPathRenames(t *testing.T) {
+	tests := []struct {
+		raw     string
+		oldPath string
+		newPath string
+	}{
+		{raw: "main.go", oldPath: "", newPath: "main.go"},
+		{raw: "old.go => new.go", oldPath: "old.go", newPath: "new.go"},
+		{raw: "pkg/{a => b}/file.go", oldPath: "pkg/a/file.go", newPath: "pkg/b/file.go"},
+		{raw: "{cmd => internal}/x.go", oldPath: "cmd/x.go", newPath: "internal/x.go"},
+		{raw: "src/{ => nested}/f.go", oldPath: "src/f.go", newPath: "src/nested/f.go"},
+		{raw: "dir/{old.go => new.go}", oldPath: "dir/old.go", newPath: "dir/new.go"},
+		{raw: "with space.go", oldPath: "", newPath: "with space.go"},
+	}
+	for _, tt := range tests {
+		oldPath, newPath := parseNumstatPath(tt.raw)
+		if oldPath != tt.oldPath || newPath != tt.newPath {
+			t.Errorf("parseNumstatPath(%q) = %q, %q; want %q, %q", tt.raw, oldPath, newPath, tt.oldPath, tt.newPath)
+		}
+	}
+}
+
+func TestGitChurnFoldsRenamesIntoCurrentPath(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	body := "package demo\n\nfunc a() int { return 1 }\nfunc b() int { return 2 }\nfunc c() int { return 3 }\nfunc d() int { return 4 }\n"
+	writeFile(t, repo, "handler.go", body)
+	gitAddCommit(t, repo, "add handler")
+	writeFile(t, repo, "handler.go", body+"func e() int { return 5 }\n")
+	gitAddCommit(t, repo, "grow handler")
+	if err := os.MkdirAll(filepath.Join(repo, "internal", "api"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	runGit(t, repo, "mv", "handler.go", "internal/api/handler.go")
+	gitAddCommit(t, repo, "move handler")
+	runGit(t, repo, "mv", "internal/api/handler.go", "internal/api/routes.go")
+	writeFile(t, repo, "internal/api/routes.go", body+"func e() int { return 5 }\nfunc f() int { return 6 }\n")
+	gitAddCommit(t, repo, "rename and extend")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	commits, _, err := gitChurn(context.Background(), churnWindow{Since: defaultHotspotSince})
+	if err != nil {
+		t.Fatalf("gitChurn: %v", err)
+	}
+	churn := churnTotals(commits)
+	if _, ghost := churn["handler.go"]; ghost {
+		t.Fatalf("expected no ghost entry for the old path: %v", churn)
+	}
+	if _, ghost := churn["internal/api/handler.go"]; ghost {
+		t.Fatalf("expected no ghost entry for the intermediate path: %v", churn)
+	}
+	// 6 lines added, 1 line added, 0 for the pure move, 1 line added.
+	if churn["internal/api/routes.go"] != 8 {
+		t.Fatalf("expected full history on routes.go, got %v", churn)
+	}
+}
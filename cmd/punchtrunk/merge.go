@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Merged SARIF.
+//
+// --sarif-merge writes one SARIF log after all modes finish: a run per
+// trunk linter, then the PunchTrunk hotspots run. Every artifact location is
+// relative to the %SRCROOT% base declared in each run's originalUriBaseIds,
+// so uploads resolve paths the same way whichever run a finding came from.
+// Lint findings in reported hotspot files carry a hotspot_rank property.
+
+const srcRootBaseID = "%SRCROOT%"
+
+// writeMergedSARIF merges the lint and hotspot results captured during this
+// run. Modes that did not run contribute no runs.
+func writeMergedSARIF(cfg *Config) error {
+	ranks := map[string]int{}
+	if cfg.hotspotReport != nil {
+		for _, h := range cfg.hotspotReport.Hotspots {
+			ranks[filepath.ToSlash(h.File)] = h.Rank
+		}
+	}
+	log := SarifLog{Version: "2.1.0", Schema: sarifSchemaURI, Runs: []SarifRun{}}
+	if cfg.lintReport != nil {
+		log.Runs = append(log.Runs, lintRunsByLinter(cfg.lintReport, cfg.TrunkVersion, ranks)...)
+	}
+	if cfg.hotspotReport != nil {
+		log.Runs = append(log.Runs, buildHotspotSARIF(cfg.hotspotReport).Runs...)
+	}
+	root, err := srcRootURI()
+	if err != nil {
+		return err
+	}
+	for i := range log.Runs {
+		rebaseRun(&log.Runs[i], root)
+	}
+	out, err := prepareReportPath(cfg, cfg.SarifMerge)
+	if err != nil {
+		return err
+	}
+	cfg.SarifMerge = out
+	if err := writeIndentedJSON(out, &log); err != nil {
+		return fmt.Errorf("write merged SARIF: %w", err)
+	}
+	cfg.log().Event("info", "sarif.merge.write", LogFields{
+		"sarif_out": out,
+		"runs":      len(log.Runs),
+	})
+	return nil
+}
+
+// lintRunsByLinter splits lint findings into one run per linter, sorted by
+// name. Linters that only failed still get a run recording the failure.
+func lintRunsByLinter(report *lintReport, trunkVersion string, ranks map[string]int) []SarifRun {
+	issues := map[string][]lintIssue{}
+	failures := map[string][]lintFailure{}
+	for _, i := range report.Issues {
+		issues[i.Linter] = append(issues[i.Linter], i)
+	}
+	for _, f := range report.Failures {
+		failures[f.Linter] = append(failures[f.Linter], f)
+	}
+	var linters []string
+	for l := range issues {
+		linters = append(linters, l)
+	}
+	for l := range failures {
+		if _, ok := issues[l]; !ok {
+			linters = append(linters, l)
+		}
+	}
+	sort.Strings(linters)
+	runs := make([]SarifRun, 0, len(linters))
+	for _, linter := range linters {
+		run := lintRun(linter, "", issues[linter], failures[linter])
+		if run.Properties == nil {
+			run.Properties = map[string]any{}
+		}
+		run.Properties["trunk_version"] = trunkVersion
+		for j := range run.Results {
+			uri := run.Results[j].Locations[0].PhysicalLocation.ArtifactLocation.URI
+			if rank, ok := ranks[uri]; ok {
+				run.Results[j].Properties["hotspot_rank"] = rank
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// srcRootURI is the working directory as a file URI ending in a slash.
+func srcRootURI() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", srcRootBaseID, err)
+	}
+	p := filepath.ToSlash(cwd)
+	if !strings.HasPrefix(p, "/") {
+		// Windows drive paths: file:///C:/repo/
+		p = "/" + p
+	}
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String(), nil
+}
+
+// rebaseRun declares %SRCROOT% and points every location of run at it.
+func rebaseRun(run *SarifRun, root string) {
+	run.OriginalURIBaseIDs = map[string]SarifArtifactLocation{srcRootBaseID: {URI: root}}
+	for i := range run.Results {
+		r := &run.Results[i]
+		for j := range r.Locations {
+			r.Locations[j].PhysicalLocation.ArtifactLocation.URIBaseID = srcRootBaseID
+		}
+		for j := range r.RelatedLocations {
+			r.RelatedLocations[j].PhysicalLocation.ArtifactLocation.URIBaseID = srcRootBaseID
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteMergedSARIF(t *testing.T) {
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	lint, err := parseTrunkCheckJSON([]byte(trunkCheckFixture))
+	if err != nil {
+		t.Fatalf("parseTrunkCheckJSON: %v", err)
+	}
+	cfg := &Config{
+		SarifMerge:   filepath.Join("reports", "merged.sarif"),
+		TrunkVersion: "1.22.0",
+		lintReport:   lint,
+		hotspotReport: &hotspotReport{Hotspots: []Hotspot{
+			{File: "main.go", Rank: 1, Score: 3},
+			{File: "src/app.ts", Rank: 2, Score: 2},
+		}},
+	}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if err := writeMergedSARIF(cfg); err != nil {
+		t.Fatalf("writeMergedSARIF: %v", err)
+	}
+	data, err := os.ReadFile(cfg.SarifMerge)
+	if err != nil {
+		t.Fatalf("read merged SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("decode merged SARIF: %v", err)
+	}
+	var tools []string
+	for _, run := range log.Runs {
+		tools = append(tools, run.Tool.Driver.Name)
+		base, ok := run.OriginalURIBaseIDs[srcRootBaseID]
+		if !ok || !strings.HasPrefix(base.URI, "file:///") || !strings.HasSuffix(base.URI, "/") {
+			t.Fatalf("run %s: unexpected originalUriBaseIds %+v", run.Tool.Driver.Name, run.OriginalURIBaseIDs)
+		}
+		for _, r := range run.Results {
+			if loc := r.Locations[0].PhysicalLocation.ArtifactLocation; loc.URIBaseID != srcRootBaseID {
+				t.Fatalf("run %s: location %+v not based on %s", run.Tool.Driver.Name, loc, srcRootBaseID)
+			}
+		}
+	}
+	want := []string{"eslint", "gomod", "markdownlint", "shellcheck", "PunchTrunk"}
+	if !slices.Equal(tools, want) {
+		t.Fatalf("runs = %v, want %v", tools, want)
+	}
+	if len(log.Runs[3].Results) != 0 || log.Runs[3].Properties["tool_failures"] == nil {
+		t.Fatalf("expected the failed linter run to record its failure, got %+v", log.Runs[3])
+	}
+	ranked := map[string]any{}
+	for _, r := range log.Runs[0].Results {
+		uri := r.Locations[0].PhysicalLocation.ArtifactLocation.URI
+		if rank, ok := r.Properties["hotspot_rank"]; ok {
+			ranked[uri] = rank
+		}
+	}
+	if len(ranked) != 1 || ranked["src/app.ts"] != float64(2) {
+		t.Fatalf("expected only src/app.ts findings to carry hotspot_rank 2, got %v", ranked)
+	}
+}
+
+func TestWriteMergedSARIFHotspotsOnly(t *testing.T) {
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	cfg := &Config{
+		SarifMerge:    "merged.sarif",
+		hotspotReport: &hotspotReport{Hotspots: []Hotspot{{File: "main.go", Rank: 1}}},
+	}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if err := writeMergedSARIF(cfg); err != nil {
+		t.Fatalf("writeMergedSARIF: %v", err)
+	}
+	data, err := os.ReadFile("merged.sarif")
+	if err != nil {
+		t.Fatalf("read merged SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("decode merged SARIF: %v", err)
+	}
+	if len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "PunchTrunk" {
+		t.Fatalf("expected only the hotspots run, got %+v", log.Runs)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hotspot ownership.
+//
+// Churn is split per author (git %aE, so .mailmap applies) to report how many
+// people touched a file in the churn window, the top contributor's share, and
+// the bus factor (fewest authors covering half the churn). CODEOWNERS supplies
+// the declared owners. knowledge_risk multiplies the top author's share by
+// how high the file ranks, so hot files known mostly to one person stand out.
+
+const (
+	// knowledgeRiskThreshold flags a hotspot as a knowledge risk.
+	knowledgeRiskThreshold = 0.7
+	// busFactorCoverage is the churn share the bus factor authors must cover.
+	busFactorCoverage = 0.5
+)
+
+// codeownersLocations follows GitHub's lookup order.
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type fileOwnership struct {
+	Authors        int      `json:"authors"`
+	TopAuthorShare float64  `json:"top_author_share"`
+	BusFactor      int      `json:"bus_factor"`
+	Owners         []string `json:"owners,omitempty"`
+	KnowledgeRisk  float64  `json:"knowledge_risk"`
+}
+
+func (o *fileOwnership) properties() map[string]any {
+	props := map[string]any{
+		"authors":             o.Authors,
+		"top_author_share":    math.Round(o.TopAuthorShare*1000) / 1000,
+		"bus_factor":          o.BusFactor,
+		"knowledge_risk":      math.Round(o.KnowledgeRisk*1000) / 1000,
+		"knowledge_risk_high": o.KnowledgeRisk >= knowledgeRiskThreshold,
+		"owned":               len(o.Owners) > 0,
+	}
+	if len(o.Owners) > 0 {
+		props["owners"] = o.Owners
+	}
+	return props
+}
+
+// authorChurn totals churned lines per file and author. Commits without an
+// author (fallback log formats) are ignored.
+func authorChurn(commits []numstatCommit) map[string]map[string]int {
+	out := map[string]map[string]int{}
+	for _, c := range commits {
+		if c.Author == "" {
+			continue
+		}
+		for _, f := range c.Files {
+			if out[f.Path] == nil {
+				out[f.Path] = map[string]int{}
+			}
+			out[f.Path][c.Author] += f.Lines()
+		}
+	}
+	return out
+}
+
+func summarizeAuthors(byAuthor map[string]int) (authors int, topShare float64, busFactor int) {
+	total := 0
+	counts := make([]int, 0, len(byAuthor))
+	for _, n := range byAuthor {
+		if n <= 0 {
+			continue
+		}
+		counts = append(counts, n)
+		total += n
+	}
+	if total == 0 {
+		return 0, 0, 0
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+	covered := 0
+	for _, n := range counts {
+		covered += n
+		busFactor++
+		if float64(covered) >= busFactorCoverage*float64(total) {
+			break
+		}
+	}
+	return len(counts), float64(counts[0]) / float64(total), busFactor
+}
+
+// attributeOwnership fills Ownership for every hotspot. hs must be sorted and
+// ranked; total is the number of ranked files before truncation.
+func attributeOwnership(hs []Hotspot, commits []numstatCommit, owners *codeowners, total int) {
+	byFile := authorChurn(commits)
+	if total < len(hs) {
+		total = len(hs)
+	}
+	for i := range hs {
+		authors, share, bus := summarizeAuthors(byFile[hs[i].File])
+		if authors == 0 && owners == nil {
+			continue
+		}
+		o := &fileOwnership{Authors: authors, TopAuthorShare: share, BusFactor: bus}
+		if owners != nil {
+			o.Owners = owners.ownersFor(hs[i].File)
+		}
+		if total > 0 && hs[i].Rank > 0 {
+			hotness := float64(total-hs[i].Rank+1) / float64(total)
+			o.KnowledgeRisk = share * hotness
+		}
+		hs[i].Ownership = o
+	}
+}
+
+type codeownersRule struct {
+	re     *regexp.Regexp
+	owners []string
+}
+
+type codeowners struct {
+	Path  string
+	rules []codeownersRule
+}
+
+// loadCodeowners reads the first CODEOWNERS file GitHub would use. It returns
+// nil when the repository has none.
+func loadCodeowners() *codeowners {
+	for _, path := range codeownersLocations {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		co := parseCodeowners(data)
+		co.Path = path
+		return co
+	}
+	return nil
+}
+
+func parseCodeowners(data []byte) *codeowners {
+	co := &codeowners{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") {
+			// Skip GitLab-style section headers.
+			continue
+		}
+		rule := codeownersRule{re: globToRegexp(fields[0])}
+		if len(fields) > 1 {
+			rule.owners = fields[1:]
+		}
+		co.rules = append(co.rules, rule)
+	}
+	return co
+}
+
+// ownersFor returns the owners of the last matching rule, as GitHub does. A
+// matching rule without owners clears ownership.
+func (co *codeowners) ownersFor(file string) []string {
+	file = strings.TrimPrefix(strings.ReplaceAll(file, "\\", "/"), "./")
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].re.MatchString(file) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCodeownersLastMatchWins(t *testing.T) {
+	co := parseCodeowners([]byte(`# Default owners
+*       @org/platform
+*.md    @org/docs # docs team
+/cmd/   @alice @bob
+cmd/generated/
+`))
+	cases := map[string][]string{
+		"main.go":                 {"@org/platform"},
+		"docs/guide.md":           {"@org/docs"},
+		"cmd/punchtrunk/main.go":  {"@alice", "@bob"},
+		"cmd/generated/schema.go": nil,
+	}
+	for file, want := range cases {
+		if got := co.ownersFor(file); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: owners %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestParseCodeownersBareDirectory(t *testing.T) {
+	co := parseCodeowners([]byte(`/docs/api @org/api-docs
+**/logs   @ops
+`))
+	cases := map[string][]string{
+		"docs/api/x.md":          {"@org/api-docs"},
+		"docs/api/v1/y.md":       {"@org/api-docs"},
+		"docs/apis/x.md":         nil,
+		"services/web/logs/a.go": {"@ops"},
+		"logs/b.go":              {"@ops"},
+	}
+	for file, want := range cases {
+		if got := co.ownersFor(file); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: owners %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestSummarizeAuthors(t *testing.T) {
+	authors, share, bus := summarizeAuthors(map[string]int{"a": 80, "b": 15, "c": 5})
+	if authors != 3 || share != 0.8 || bus != 1 {
+		t.Fatalf("unexpected summary: authors=%d share=%f bus=%d", authors, share, bus)
+	}
+	_, _, bus = summarizeAuthors(map[string]int{"a": 30, "b": 30, "c": 40})
+	if bus != 2 {
+		t.Fatalf("expected bus factor 2 for an even spread, got %d", bus)
+	}
+}
+
+func TestParseNumstatHeaderAuthor(t *testing.T) {
+	c := parseNumstatHeader("abc123 1700000000 Dev@Example.com")
+	if c.SHA != "abc123" || c.Time.Unix() != 1700000000 || c.Author != "dev@example.com" {
+		t.Fatalf("unexpected header: %+v", c)
+	}
+}
+
+func TestAnalyzeHotspotsOwnership(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	if err := os.MkdirAll(repo+"/.github", 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, repo, ".github/CODEOWNERS", "*.go @org/backend\n")
+	writeFile(t, repo, "solo.go", "package a\n\nfunc Solo() {}\n")
+	writeFile(t, repo, "shared.go", "package a\n\nfunc Shared() {}\n")
+	gitAddCommit(t, repo, "initial")
+	writeFile(t, repo, "shared.go", "package a\n\nfunc Shared() int { return 1 }\n")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "other author", "--author=Other Dev <other@example.com>")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	report, err := analyzeHotspots(context.Background(), &Config{BaseBranch: "HEAD", Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	if report.Codeowners != ".github/CODEOWNERS" {
+		t.Fatalf("expected CODEOWNERS to be detected, got %q", report.Codeowners)
+	}
+	byFile := map[string]*fileOwnership{}
+	for _, h := range report.Hotspots {
+		byFile[h.File] = h.Ownership
+	}
+	solo, shared := byFile["solo.go"], byFile["shared.go"]
+	if solo == nil || solo.Authors != 1 || solo.TopAuthorShare != 1 || solo.BusFactor != 1 {
+		t.Fatalf("unexpected ownership for solo.go: %+v", solo)
+	}
+	if shared == nil || shared.Authors != 2 || shared.TopAuthorShare >= 1 {
+		t.Fatalf("unexpected ownership for shared.go: %+v", shared)
+	}
+	if !reflect.DeepEqual(solo.Owners, []string{"@org/backend"}) {
+		t.Fatalf("expected CODEOWNERS owners, got %v", solo.Owners)
+	}
+	if solo.KnowledgeRisk <= 0 || solo.KnowledgeRisk > 1 {
+		t.Fatalf("expected knowledge risk in (0,1], got %f", solo.KnowledgeRisk)
+	}
+}
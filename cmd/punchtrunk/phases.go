@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Hotspot phases.
+//
+// A hotspot run reads git history, estimates complexity, scores and ranks
+// files, then serializes reports. Each phase logs a hotspots.phase event with
+// its duration so slow runs can be attributed. Complexity estimation reads
+// and parses every churned file; it runs on a pool of --max-procs workers
+// and stops handing out files once the --timeout context is cancelled.
+
+const (
+	phaseGitHistory    = "git_history"
+	phaseComplexity    = "complexity"
+	phaseScoring       = "scoring"
+	phaseRegions       = "regions"
+	phaseSerialization = "serialization"
+)
+
+// logPhase emits a hotspots.phase event for a phase that began at start.
+func logPhase(cfg *Config, phase string, start time.Time, fields LogFields) {
+	out := LogFields{
+		"phase":       phase,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	for k, v := range fields {
+		out[k] = v
+	}
+	cfg.log().Event("info", "hotspots.phase", out)
+}
+
+// workerCount sizes a pool for n jobs by --max-procs (0 = CPU cores).
+func (cfg *Config) workerCount(n int) int {
+	workers := runtime.NumCPU()
+	if cfg != nil && cfg.MaxProcs > 0 {
+		workers = cfg.MaxProcs
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// computeComplexity estimates every file in files concurrently. Unreadable
+// files score zero, as before; cancellation aborts the whole phase.
+func computeComplexity(ctx context.Context, cfg *Config, files map[string]int, mode string) (map[string]fileComplexity, error) {
+	paths := make([]string, 0, len(files))
+	for f := range files {
+		paths = append(paths, f)
+	}
+	sort.Strings(paths)
+	cache := cfg.hotspotCache()
+	results := make([]fileComplexity, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := cfg.workerCount(len(paths)); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], _ = cache.estimateComplexity(paths[i], mode)
+			}
+		}()
+	}
+	var err error
+feed:
+	for i := range paths {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("complexity analysis interrupted: %w", err)
+	}
+	out := make(map[string]fileComplexity, len(paths))
+	for i, f := range paths {
+		out[f] = results[i]
+	}
+	return out, nil
+}
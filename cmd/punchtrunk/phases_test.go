@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestComputeComplexityMatchesSequential(t *testing.T) {
+	dir := t.TempDir()
+	oldCwd := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, oldCwd) })
+	files := map[string]int{"missing.go": 1}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("f%02d.go", i)
+		body := "package demo\n\nfunc f(x int) int {\n"
+		for j := 0; j < i; j++ {
+			body += fmt.Sprintf("\tif x > %d {\n\t\tx--\n\t}\n", j)
+		}
+		writeFile(t, dir, name, body+"\treturn x\n}\n")
+		files[name] = i
+	}
+	for _, procs := range []int{1, 4, 64} {
+		cfg := &Config{MaxProcs: procs}
+		got, err := computeComplexity(context.Background(), cfg, files, complexityModeAuto)
+		if err != nil {
+			t.Fatalf("computeComplexity(%d): %v", procs, err)
+		}
+		for name := range files {
+			want, _ := estimateComplexity(name, complexityModeAuto)
+			if !reflect.DeepEqual(got[name], want) {
+				t.Fatalf("max-procs %d: %s = %+v, want %+v", procs, name, got[name], want)
+			}
+		}
+	}
+	if got := (&Config{MaxProcs: 3}).workerCount(10); got != 3 {
+		t.Fatalf("expected 3 workers, got %d", got)
+	}
+	if got := (&Config{MaxProcs: 8}).workerCount(2); got != 2 {
+		t.Fatalf("expected workers capped at job count, got %d", got)
+	}
+}
+
+func TestComputeComplexityHonoursCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := computeComplexity(ctx, &Config{MaxProcs: 2}, map[string]int{"a.go": 1, "b.go": 1}, complexityModeAuto)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRunHotspotsLogsPhases(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "main.go", "package main\n\nfunc main() {}\n")
+	gitAddCommit(t, repo, "initial")
+	writeFile(t, repo, "main.go", "package main\n\nfunc main() {\n\tif true {\n\t\tprintln()\n\t}\n}\n")
+	gitAddCommit(t, repo, "grow")
+	oldCwd := mustChdir(t, repo)
+	t.Cleanup(func() { mustChdir(t, oldCwd) })
+
+	var buf bytes.Buffer
+	cfg := &Config{BaseBranch: "HEAD~1", MaxProcs: 2, SarifOut: filepath.Join(repo, "reports", "hotspots.sarif")}
+	cfg.logger = newEventLogger(&buf, true)
+	if err := runHotspots(context.Background(), cfg); err != nil {
+		t.Fatalf("runHotspots: %v", err)
+	}
+	phases := map[string]map[string]any{}
+	dec := json.NewDecoder(&buf)
+	for {
+		event := map[string]any{}
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("decode log: %v", err)
+		}
+		if event["event"] == "hotspots.phase" {
+			phases[event["phase"].(string)] = event
+		}
+	}
+	for _, phase := range []string{phaseGitHistory, phaseComplexity, phaseScoring, phaseSerialization} {
+		event, ok := phases[phase]
+		if !ok {
+			t.Fatalf("missing %s phase event; got %v", phase, phases)
+		}
+		if _, ok := event["duration_ms"].(float64); !ok {
+			t.Fatalf("%s phase without duration_ms: %v", phase, event)
+		}
+	}
+	if phases[phaseComplexity]["workers"] != float64(1) {
+		t.Fatalf("expected one worker for one file, got %v", phases[phaseComplexity]["workers"])
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Function-level hotspot attribution.
+//
+// For the top hotspots we run `git blame --porcelain` and treat every current
+// line whose blamed commit falls inside the churn window as recently churned.
+// Those lines are attributed to the functions reported by the complexity
+// estimator, or grouped into contiguous line ranges when no function data is
+// available, so SARIF can point reviewers at the hot code instead of line 1.
+
+const (
+	defaultHotspotRegionLimit = 100
+	maxRegionsPerHotspot      = 5
+	// regionMergeGap joins churned line runs separated by a few untouched lines.
+	regionMergeGap = 3
+)
+
+type hotspotRegion struct {
+	Name      string `json:"name,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Churn     int    `json:"churn"`
+}
+
+func (r hotspotRegion) describe() string {
+	if r.Name != "" {
+		return fmt.Sprintf("%s (lines %d-%d, %d recently changed)", r.Name, r.StartLine, r.EndLine, r.Churn)
+	}
+	return fmt.Sprintf("lines %d-%d (%d recently changed)", r.StartLine, r.EndLine, r.Churn)
+}
+
+func (r hotspotRegion) sarifLocation(uri string, id int) SarifLocation {
+	loc := SarifLocation{
+		PhysicalLocation: SarifPhysicalLocation{
+			ArtifactLocation: SarifArtifactLocation{URI: uri},
+			Region:           &SarifRegion{StartLine: r.StartLine, EndLine: r.EndLine},
+		},
+	}
+	if id > 0 {
+		loc.ID = id
+		loc.Message = &SarifMessage{Text: r.describe()}
+	}
+	if r.Name != "" {
+		loc.LogicalLocations = []SarifLogicalLocation{{
+			Name:               r.Name,
+			FullyQualifiedName: fmt.Sprintf("%s:%s", uri, r.Name),
+			Kind:               "function",
+		}}
+	}
+	return loc
+}
+
+func attributeHotspotRegions(ctx context.Context, cfg *Config, hs []Hotspot, commits []numstatCommit) {
+	window := map[string]bool{}
+	for _, c := range commits {
+		if c.SHA != "" {
+			window[c.SHA] = true
+		}
+	}
+	if len(window) == 0 {
+		return
+	}
+	limit := cfg.HotspotRegionLimit
+	if limit > len(hs) {
+		limit = len(hs)
+	}
+	for i := 0; i < limit; i++ {
+		if ctx.Err() != nil {
+			return
+		}
+		blame, err := gitBlameLines(ctx, hs[i].File)
+		if err != nil {
+			if cfg.Verbose {
+				cfg.log().Infof("git blame %s failed: %v; skipping region attribution", hs[i].File, err)
+			}
+			continue
+		}
+		hs[i].Regions = hotspotRegions(blame, window, hs[i].Functions)
+	}
+}
+
+var blameHeaderPattern = regexp.MustCompile(`^([0-9a-f]{40}) \d+ (\d+)`)
+
+// gitBlameLines maps current line numbers (1-based) to the commit that last
+// touched them.
+func gitBlameLines(ctx context.Context, file string) (map[int]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "blame", "--porcelain", "--", file)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseBlamePorcelain(stdout.String()), nil
+}
+
+func parseBlamePorcelain(output string) map[int]string {
+	lines := map[int]string{}
+	for _, line := range strings.Split(output, "\n") {
+		m := blameHeaderPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		final, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		lines[final] = m[1]
+	}
+	return lines
+}
+
+// hotspotRegions counts churned lines per function, or per contiguous line
+// range when functions are unknown, and returns the hottest regions first.
+func hotspotRegions(blame map[int]string, window map[string]bool, functions []functionComplexity) []hotspotRegion {
+	var churned []int
+	for line, sha := range blame {
+		if window[sha] {
+			churned = append(churned, line)
+		}
+	}
+	if len(churned) == 0 {
+		return nil
+	}
+	sort.Ints(churned)
+	var regions []hotspotRegion
+	if len(functions) > 0 {
+		for _, fn := range functions {
+			count := 0
+			for _, line := range churned {
+				if line >= fn.StartLine && line <= fn.EndLine {
+					count++
+				}
+			}
+			if count > 0 {
+				regions = append(regions, hotspotRegion{Name: fn.Name, StartLine: fn.StartLine, EndLine: fn.EndLine, Churn: count})
+			}
+		}
+	}
+	if len(regions) == 0 {
+		regions = lineRangeRegions(churned)
+	}
+	sort.SliceStable(regions, func(i, j int) bool {
+		if regions[i].Churn != regions[j].Churn {
+			return regions[i].Churn > regions[j].Churn
+		}
+		return regions[i].StartLine < regions[j].StartLine
+	})
+	if len(regions) > maxRegionsPerHotspot {
+		regions = regions[:maxRegionsPerHotspot]
+	}
+	return regions
+}
+
+func lineRangeRegions(sortedLines []int) []hotspotRegion {
+	var regions []hotspotRegion
+	for _, line := range sortedLines {
+		if n := len(regions); n > 0 && line-regions[n-1].EndLine <= regionMergeGap+1 {
+			regions[n-1].EndLine = line
+			regions[n-1].Churn++
+			continue
+		}
+		regions = append(regions, hotspotRegion{StartLine: line, EndLine: line, Churn: 1})
+	}
+	return regions
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBlamePorcelain(t *testing.T) {
+	shaA := strings.Repeat("a", 40)
+	shaB := strings.Repeat("b", 40)
+	output := shaA + " 1 1 2\nauthor A\ncommitter-time 1700000000\nfilename x.go\n\tpackage x\n" +
+		shaA + " 2 2\n\t\n" +
+		shaB + " 5 3 1\nauthor B\nfilename x.go\n\tfunc f() {}\n"
+	got := parseBlamePorcelain(output)
+	if len(got) != 3 || got[1] != shaA || got[2] != shaA || got[3] != shaB {
+		t.Fatalf("unexpected blame map: %v", got)
+	}
+}
+
+func TestHotspotRegionsPrefersFunctions(t *testing.T) {
+	blame := map[int]string{1: "old", 2: "new", 3: "new", 4: "old", 6: "new", 10: "new", 11: "old"}
+	window := map[string]bool{"new": true}
+	functions := []functionComplexity{
+		{Name: "a", StartLine: 1, EndLine: 4},
+		{Name: "b", StartLine: 9, EndLine: 11},
+		{Name: "cold", StartLine: 20, EndLine: 30},
+	}
+	regions := hotspotRegions(blame, window, functions)
+	if len(regions) != 2 {
+		t.Fatalf("expected 2 hot functions, got %+v", regions)
+	}
+	if regions[0].Name != "a" || regions[0].Churn != 2 || regions[1].Name != "b" {
+		t.Fatalf("unexpected region order: %+v", regions)
+	}
+
+	ranges := hotspotRegions(blame, window, nil)
+	if len(ranges) != 1 || ranges[0].StartLine != 2 || ranges[0].EndLine != 10 || ranges[0].Churn != 4 {
+		t.Fatalf("expected merged line range 2-10, got %+v", ranges)
+	}
+}
+
+func TestHotspotRegionsEndToEnd(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "svc.go", `package svc
+
+func stable() int {
+	return 1
+}
+
+func hot(x int) int {
+	return x
+}
+`)
+	gitAddCommit(t, repo, "initial")
+	writeFile(t, repo, "svc.go", `package svc
+
+func stable() int {
+	return 1
+}
+
+func hot(x int) int {
+	if x > 10 {
+		return x * 2
+	}
+	return x
+}
+`)
+	gitAddCommit(t, repo, "rework hot")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{
+		BaseBranch:         "HEAD~1",
+		Timeout:            10 * time.Second,
+		HotspotSince:       "HEAD~1",
+		HotspotRegionLimit: 10,
+	}
+	report, err := analyzeHotspots(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("analyzeHotspots: %v", err)
+	}
+	if len(report.Hotspots) != 1 {
+		t.Fatalf("expected a single hotspot, got %+v", report.Hotspots)
+	}
+	regions := report.Hotspots[0].Regions
+	if len(regions) != 1 || regions[0].Name != "hot" || regions[0].StartLine != 7 || regions[0].EndLine != 12 {
+		t.Fatalf("expected churn attributed to hot(), got %+v", regions)
+	}
+
+	path := filepath.Join(repo, "out.sarif")
+	if err := writeHotspotSARIF(path, report); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	loc := log.Runs[0].Results[0].Locations[0]
+	if loc.PhysicalLocation.Region == nil || loc.PhysicalLocation.Region.StartLine != 7 || loc.PhysicalLocation.Region.EndLine != 12 {
+		t.Fatalf("expected SARIF region 7-12, got %+v", loc.PhysicalLocation.Region)
+	}
+	if len(loc.LogicalLocations) != 1 || loc.LogicalLocations[0].Name != "hot" || loc.LogicalLocations[0].Kind != "function" {
+		t.Fatalf("expected logical location for hot(), got %+v", loc.LogicalLocations)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Hotspot rollups.
+//
+// Architecture reviews compare subsystems rather than files, so every ranked
+// file is also aggregated per directory (cut at --hotspot-rollup-depth), per
+// Go module (nearest go.mod) and per configured --hotspot-rollup-prefix.
+// Rollups are written to the --hotspot-json report, and --hotspot-treemap
+// writes the same ranking as a nested name/children/value tree that d3,
+// ECharts and similar treemap widgets read directly.
+
+const (
+	rollupDirectory = "directory"
+	rollupModule    = "module"
+	rollupPrefix    = "prefix"
+
+	defaultHotspotRollupDepth = 2
+	// rollupOther groups files outside every configured prefix.
+	rollupOther = "(other)"
+	// rollupNoModule groups files outside every Go module.
+	rollupNoModule = "(none)"
+)
+
+type hotspotRollup struct {
+	Path           string  `json:"path"`
+	Module         string  `json:"module,omitempty"`
+	Files          int     `json:"files"`
+	Changed        int     `json:"changed_files"`
+	Churn          int     `json:"churn"`
+	Score          float64 `json:"score"`
+	MaxScore       float64 `json:"max_score"`
+	MeanComplexity float64 `json:"mean_complexity"`
+	TopFile        string  `json:"top_file"`
+}
+
+type hotspotRollups map[string][]hotspotRollup
+
+func (cfg *Config) rollupDepth() int {
+	if cfg == nil || cfg.HotspotRollupDepth <= 0 {
+		return defaultHotspotRollupDepth
+	}
+	return cfg.HotspotRollupDepth
+}
+
+func (cfg *Config) rollupPrefixes() []string {
+	if cfg == nil {
+		return nil
+	}
+	var prefixes []string
+	for _, raw := range cfg.HotspotRollupPrefix {
+		for _, p := range splitCSV(raw) {
+			p = strings.Trim(filepath.ToSlash(p), "/")
+			if p != "" {
+				prefixes = append(prefixes, p)
+			}
+		}
+	}
+	return prefixes
+}
+
+// computeRollups aggregates hs (the full ranking, before truncation) along
+// every rollup dimension.
+func computeRollups(hs []Hotspot, depth int, prefixes []string) hotspotRollups {
+	modules := newModuleResolver()
+	keyers := map[string]func(string) string{
+		rollupDirectory: func(file string) string { return directoryKey(file, depth) },
+		rollupModule:    modules.moduleDir,
+	}
+	if len(prefixes) > 0 {
+		keyers[rollupPrefix] = func(file string) string { return prefixKey(file, prefixes) }
+	}
+	out := hotspotRollups{}
+	for kind, key := range keyers {
+		groups := map[string]*hotspotRollup{}
+		complexity := map[string]float64{}
+		for _, h := range hs {
+			file := filepath.ToSlash(h.File)
+			k := key(file)
+			g := groups[k]
+			if g == nil {
+				g = &hotspotRollup{Path: k, MaxScore: h.Score, TopFile: file}
+				groups[k] = g
+			}
+			g.Files++
+			g.Churn += h.Churn
+			g.Score += h.Score
+			complexity[k] += h.Complexity
+			if h.Changed {
+				g.Changed++
+			}
+			if h.Score > g.MaxScore {
+				g.MaxScore, g.TopFile = h.Score, file
+			}
+		}
+		rows := make([]hotspotRollup, 0, len(groups))
+		for k, g := range groups {
+			g.MeanComplexity = complexity[k] / float64(g.Files)
+			if kind == rollupModule {
+				g.Module = modules.modulePath(k)
+			}
+			rows = append(rows, *g)
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Score != rows[j].Score {
+				return rows[i].Score > rows[j].Score
+			}
+			return rows[i].Path < rows[j].Path
+		})
+		out[kind] = rows
+	}
+	return out
+}
+
+func directoryKey(file string, depth int) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// prefixKey returns the longest configured prefix containing file.
+func prefixKey(file string, prefixes []string) string {
+	best := ""
+	for _, p := range prefixes {
+		if (file == p || strings.HasPrefix(file, p+"/")) && len(p) > len(best) {
+			best = p
+		}
+	}
+	if best == "" {
+		return rollupOther
+	}
+	return best
+}
+
+// moduleResolver maps files to the directory of their nearest go.mod, walking
+// up from the file to the working directory (the repository root).
+type moduleResolver struct {
+	dirs  map[string]string
+	paths map[string]string
+}
+
+func newModuleResolver() *moduleResolver {
+	return &moduleResolver{dirs: map[string]string{}, paths: map[string]string{}}
+}
+
+func (m *moduleResolver) moduleDir(file string) string {
+	dir := path.Dir(file)
+	var visited []string
+	result := rollupNoModule
+	for {
+		if cached, ok := m.dirs[dir]; ok {
+			result = cached
+			break
+		}
+		visited = append(visited, dir)
+		if modPath, ok := readModulePath(filepath.Join(filepath.FromSlash(dir), "go.mod")); ok {
+			result = dir
+			m.paths[dir] = modPath
+			break
+		}
+		if dir == "." || dir == "/" {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+	for _, v := range visited {
+		m.dirs[v] = result
+	}
+	return result
+}
+
+func (m *moduleResolver) modulePath(dir string) string {
+	return m.paths[dir]
+}
+
+func readModulePath(goMod string) (string, bool) {
+	data, err := os.ReadFile(goMod)
+	if err != nil {
+		return "", false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), true
+		}
+	}
+	return "", true
+}
+
+// hotspotJSONReport is the --hotspot-json document. Its hotspots array is
+// also accepted by --hotspot-baseline.
+type hotspotJSONReport struct {
+	GeneratedAt     string            `json:"generated_at"`
+	Version         string            `json:"version"`
+	ChurnWindow     map[string]any    `json:"churn_window"`
+	ChurnModel      map[string]any    `json:"churn_model,omitempty"`
+	ComplexityModel string            `json:"complexity_model,omitempty"`
+	Filters         map[string]any    `json:"filters,omitempty"`
+	Selection       *hotspotSelection `json:"selection,omitempty"`
+	Trend           *trendSummary     `json:"trend,omitempty"`
+	Hotspots        []Hotspot         `json:"hotspots"`
+	Rollups         hotspotRollups    `json:"rollups,omitempty"`
+}
+
+func buildHotspotJSON(report *hotspotReport) hotspotJSONReport {
+	doc := hotspotJSONReport{
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
+		Version:         Version,
+		ChurnWindow:     report.Window.properties(),
+		ComplexityModel: report.ComplexityMode,
+		Filters:         report.Filters,
+		Selection:       report.Selection,
+		Trend:           report.Trend,
+		Hotspots:        report.Hotspots,
+		Rollups:         report.Rollups,
+	}
+	if report.Model.Name != "" {
+		doc.ChurnModel = report.Model.properties()
+	}
+	if doc.Hotspots == nil {
+		doc.Hotspots = []Hotspot{}
+	}
+	return doc
+}
+
+func writeHotspotJSON(path string, report *hotspotReport) error {
+	doc := buildHotspotJSON(report)
+	return writeIndentedJSON(path, &doc)
+}
+
+type treemapNode struct {
+	Name     string         `json:"name"`
+	Path     string         `json:"path,omitempty"`
+	Value    float64        `json:"value,omitempty"`
+	Score    float64        `json:"score,omitempty"`
+	Churn    int            `json:"churn,omitempty"`
+	Changed  bool           `json:"changed,omitempty"`
+	Children []*treemapNode `json:"children,omitempty"`
+}
+
+// buildTreemap nests hotspots by directory. Leaf value is the score clamped
+// at zero (treemaps cannot size negative areas); parents leave value unset so
+// renderers sum their children.
+func buildTreemap(hs []Hotspot) *treemapNode {
+	root := &treemapNode{Name: "."}
+	index := map[string]*treemapNode{"": root}
+	for _, h := range hs {
+		file := filepath.ToSlash(h.File)
+		parts := strings.Split(file, "/")
+		parent := root
+		prefix := ""
+		for _, dir := range parts[:len(parts)-1] {
+			if prefix == "" {
+				prefix = dir
+			} else {
+				prefix += "/" + dir
+			}
+			node, ok := index[prefix]
+			if !ok {
+				node = &treemapNode{Name: dir, Path: prefix}
+				index[prefix] = node
+				parent.Children = append(parent.Children, node)
+			}
+			parent = node
+		}
+		value := h.Score
+		if value < 0 {
+			value = 0
+		}
+		parent.Children = append(parent.Children, &treemapNode{
+			Name:    parts[len(parts)-1],
+			Path:    file,
+			Value:   value,
+			Score:   h.Score,
+			Churn:   h.Churn,
+			Changed: h.Changed,
+		})
+	}
+	return root
+}
+
+func writeHotspotTreemap(path string, report *hotspotReport) error {
+	return writeIndentedJSON(path, buildTreemap(report.Hotspots))
+}
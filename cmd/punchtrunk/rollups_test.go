@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestComputeRollups(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, repo, "go.mod", "module example.com/root\n")
+	if err := os.MkdirAll(filepath.Join(repo, "services", "api"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, repo, "services/api/go.mod", "module example.com/api\n")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	hs := []Hotspot{
+		{File: "services/api/handlers/user.go", Churn: 10, Score: 5, Complexity: 4, Changed: true},
+		{File: "services/api/main.go", Churn: 4, Score: 2, Complexity: 2},
+		{File: "services/web/app.ts", Churn: 6, Score: 3, Complexity: 6},
+		{File: "main.go", Churn: 1, Score: 0.5, Complexity: 1},
+	}
+	rollups := computeRollups(hs, 2, []string{"services/api"})
+
+	dirs := rollups[rollupDirectory]
+	if len(dirs) != 3 || dirs[0].Path != "services/api" || dirs[0].Files != 2 || dirs[0].Score != 7 || dirs[0].Churn != 14 || dirs[0].Changed != 1 {
+		t.Fatalf("unexpected directory rollups: %+v", dirs)
+	}
+	if dirs[0].TopFile != "services/api/handlers/user.go" || dirs[0].MeanComplexity != 3 {
+		t.Fatalf("unexpected top file or complexity: %+v", dirs[0])
+	}
+
+	mods := map[string]hotspotRollup{}
+	for _, r := range rollups[rollupModule] {
+		mods[r.Path] = r
+	}
+	if mods["services/api"].Files != 2 || mods["services/api"].Module != "example.com/api" {
+		t.Fatalf("expected nested module rollup, got %+v", mods)
+	}
+	if mods["."].Files != 2 || mods["."].Module != "example.com/root" {
+		t.Fatalf("expected root module to hold the rest, got %+v", mods)
+	}
+
+	prefixes := rollups[rollupPrefix]
+	if len(prefixes) != 2 || prefixes[0].Path != "services/api" || prefixes[1].Path != rollupOther {
+		t.Fatalf("unexpected prefix rollups: %+v", prefixes)
+	}
+}
+
+func TestBuildTreemap(t *testing.T) {
+	root := buildTreemap([]Hotspot{
+		{File: "cmd/app/main.go", Score: 3, Churn: 5},
+		{File: "cmd/app/util.go", Score: -0.5, Churn: 1},
+		{File: "README.md", Score: 1},
+	})
+	if len(root.Children) != 2 || root.Children[0].Name != "cmd" || root.Children[1].Name != "README.md" {
+		t.Fatalf("unexpected root children: %+v", root.Children)
+	}
+	app := root.Children[0].Children[0]
+	if app.Path != "cmd/app" || len(app.Children) != 2 {
+		t.Fatalf("unexpected nesting: %+v", app)
+	}
+	if app.Children[0].Value != 3 || app.Children[1].Value != 0 || app.Children[1].Score != -0.5 {
+		t.Fatalf("expected negative scores clamped in value only: %+v %+v", app.Children[0], app.Children[1])
+	}
+}
+
+func TestRunHotspotsWritesJSONAndTreemap(t *testing.T) {
+	repo := t.TempDir()
+	gitInit(t, repo)
+	writeFile(t, repo, "go.mod", "module example.com/demo\n")
+	if err := os.MkdirAll(filepath.Join(repo, "pkg", "core"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, repo, "pkg/core/core.go", "package core\n\nfunc F(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\treturn 0\n}\n")
+	gitAddCommit(t, repo, "initial")
+	oldCwd := mustChdir(t, repo)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	cfg := &Config{
+		BaseBranch:     "HEAD",
+		Timeout:        10 * time.Second,
+		HotspotJSON:    filepath.Join(repo, "reports", "hotspots.json"),
+		HotspotTreemap: filepath.Join(repo, "reports", "treemap.json"),
+	}
+	cfg.logger = newEventLogger(io.Discard, false)
+	if err := runHotspots(context.Background(), cfg); err != nil {
+		t.Fatalf("runHotspots: %v", err)
+	}
+	data, err := os.ReadFile(cfg.HotspotJSON)
+	if err != nil {
+		t.Fatalf("read JSON: %v", err)
+	}
+	var doc struct {
+		Hotspots []Hotspot                  `json:"hotspots"`
+		Rollups  map[string][]hotspotRollup `json:"rollups"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal JSON: %v", err)
+	}
+	if len(doc.Hotspots) != 2 || doc.Hotspots[0].Rank != 1 {
+		t.Fatalf("unexpected hotspots: %+v", doc.Hotspots)
+	}
+	if mods := doc.Rollups[rollupModule]; len(mods) != 1 || mods[0].Module != "example.com/demo" || mods[0].Files != 2 {
+		t.Fatalf("unexpected module rollups: %+v", doc.Rollups)
+	}
+	if baseline, err := loadHotspotBaseline(cfg.HotspotJSON); err != nil || len(baseline) != 2 {
+		t.Fatalf("expected JSON report to load as a baseline, got %v, %v", baseline, err)
+	}
+	var tree treemapNode
+	data, err = os.ReadFile(cfg.HotspotTreemap)
+	if err != nil {
+		t.Fatalf("read treemap: %v", err)
+	}
+	if err := json.Unmarshal(data, &tree); err != nil || len(tree.Children) != 2 {
+		t.Fatalf("unexpected treemap: %+v, %v", tree, err)
+	}
+}
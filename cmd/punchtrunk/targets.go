@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Explicit targets.
+//
+// By default fmt and lint leave file selection to trunk's hold-the-line
+// change detection. --files, --files-from and --changed-since pass an
+// explicit list instead, so pre-commit hooks and editor integrations run on
+// exactly the files they care about. The list is resolved once per run (stdin
+// can only be read once) and split into batches that stay under the
+// platform's command-line limit, with one trunk invocation per batch.
+
+// maxTargetArgBytes bounds the file arguments of one trunk invocation.
+// Windows caps a whole command line at 32767 characters; elsewhere ARG_MAX
+// (arguments plus environment) is usually 2 MiB or more, and the budget stays
+// well below it to leave room for large CI environments.
+var maxTargetArgBytes = func() int {
+	if runtime.GOOS == "windows" {
+		return 24 << 10
+	}
+	return 96 << 10
+}()
+
+// targeted reports whether fmt and lint run on an explicit file list.
+func (cfg *Config) targeted() bool {
+	return cfg != nil && (len(cfg.Files) > 0 || strings.TrimSpace(cfg.FilesFrom) != "" || strings.TrimSpace(cfg.ChangedSince) != "")
+}
+
+// targetFiles resolves the explicit file list once per run.
+func (cfg *Config) targetFiles(ctx context.Context) ([]string, error) {
+	cfg.targetsOnce.Do(func() {
+		cfg.targets, cfg.targetsErr = resolveTargets(ctx, cfg)
+	})
+	return cfg.targets, cfg.targetsErr
+}
+
+func resolveTargets(ctx context.Context, cfg *Config) ([]string, error) {
+	var files []string
+	for _, f := range cfg.Files {
+		files = append(files, splitCSV(f)...)
+	}
+	if from := strings.TrimSpace(cfg.FilesFrom); from != "" {
+		var r io.Reader = os.Stdin
+		if from != "-" {
+			f, err := os.Open(from)
+			if err != nil {
+				return nil, fmt.Errorf("files-from: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		listed, err := readFileList(r)
+		if err != nil {
+			return nil, fmt.Errorf("files-from %s: %w", from, err)
+		}
+		files = append(files, listed...)
+	}
+	if ref := strings.TrimSpace(cfg.ChangedSince); ref != "" {
+		// An explicit ref must resolve: falling back to the last commit, as
+		// hotspots do for a missing base branch, would quietly check the
+		// wrong files.
+		if !gitResolvesCommit(ctx, ref) {
+			return nil, fmt.Errorf("changed-since %s: unknown revision", ref)
+		}
+		changed, degraded, err := gitChangedFilesSince(ctx, cfg, ref)
+		if err != nil {
+			return nil, fmt.Errorf("changed-since %s: %w", ref, err)
+		}
+		if degraded {
+			return nil, fmt.Errorf("changed-since %s: cannot diff %s...HEAD", ref, ref)
+		}
+		for f := range changed {
+			// Deleted files cannot be formatted or linted.
+			if _, err := os.Stat(filepath.FromSlash(f)); err != nil {
+				if cfg.Verbose {
+					cfg.log().Infof("changed-since: skipping %s: %v", f, err)
+				}
+				continue
+			}
+			files = append(files, f)
+		}
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, f := range files {
+		f = filepath.ToSlash(filepath.Clean(f))
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	sort.Strings(out)
+	cfg.log().Event("info", "targets.resolve", LogFields{
+		"count":         len(out),
+		"files_from":    cfg.FilesFrom,
+		"changed_since": cfg.ChangedSince,
+	})
+	return out, nil
+}
+
+// readFileList reads one path per line, or NUL-separated paths as printed by
+// `git diff -z --name-only`. Paths are kept verbatim, apart from a line's
+// trailing `\r`, so names with leading or trailing spaces survive. Blank
+// entries are skipped.
+func readFileList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sep := []byte("\n")
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = []byte{0}
+	}
+	var files []string
+	for _, raw := range bytes.Split(data, sep) {
+		f := string(raw)
+		if sep[0] == '\n' {
+			f = strings.TrimSuffix(f, "\r")
+			if strings.TrimSpace(f) == "" {
+				continue
+			}
+		}
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// trunkArgBatches returns the trunk argument lists to run: base alone when
+// trunk picks the files, otherwise base plus each batch of targets. It
+// returns no lists when an explicit target list is empty.
+func trunkArgBatches(ctx context.Context, cfg *Config, base []string) ([][]string, error) {
+	if !cfg.targeted() {
+		return [][]string{base}, nil
+	}
+	files, err := cfg.targetFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return batchTargets(base, files, maxTargetArgBytes), nil
+}
+
+// batchTargets splits files so each list's file arguments stay within limit
+// bytes; a single longer path still gets a list of its own.
+func batchTargets(base, files []string, limit int) [][]string {
+	var batches [][]string
+	var current []string
+	size := 0
+	for _, f := range files {
+		n := len(f) + 1 // separator or NUL terminator
+		if len(current) > 0 && size+n > limit {
+			batches = append(batches, append(append([]string{}, base...), current...))
+			current, size = nil, 0
+		}
+		current = append(current, f)
+		size += n
+	}
+	if len(current) > 0 {
+		batches = append(batches, append(append([]string{}, base...), current...))
+	}
+	return batches
+}
+
+// describeTargets summarises the target flags for the dry-run plan without
+// resolving them.
+func (cfg *Config) describeTargets() string {
+	var parts []string
+	if len(cfg.Files) > 0 {
+		parts = append(parts, strings.Join(cfg.Files, ","))
+	}
+	if cfg.FilesFrom == "-" {
+		parts = append(parts, "files listed on stdin")
+	} else if cfg.FilesFrom != "" {
+		parts = append(parts, "files listed in "+cfg.FilesFrom)
+	}
+	if cfg.ChangedSince != "" {
+		parts = append(parts, fmt.Sprintf("files changed in %s...HEAD", cfg.ChangedSince))
+	}
+	return "targets " + strings.Join(parts, " + ")
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBatchTargets(t *testing.T) {
+	base := []string{"check", "--output=json"}
+	files := []string{"a.go", "b.go", "c.go", "a-much-longer-name.go"}
+	batches := batchTargets(base, files, 10)
+	want := [][]string{
+		{"check", "--output=json", "a.go", "b.go"},
+		{"check", "--output=json", "c.go"},
+		{"check", "--output=json", "a-much-longer-name.go"},
+	}
+	if len(batches) != len(want) {
+		t.Fatalf("batches = %v, want %v", batches, want)
+	}
+	for i := range want {
+		if !slices.Equal(batches[i], want[i]) {
+			t.Fatalf("batch %d = %v, want %v", i, batches[i], want[i])
+		}
+	}
+	if len(batchTargets(base, nil, 10)) != 0 {
+		t.Fatalf("expected no batches without files")
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	gitInit(t, dir)
+	writeFile(t, dir, "keep.go", "package keep\n")
+	writeFile(t, dir, "gone.go", "package gone\n")
+	gitAddCommit(t, dir, "base")
+	runGit(t, dir, "tag", "base")
+	writeFile(t, dir, "keep.go", "package keep\n\nvar x = 1\n")
+	writeFile(t, dir, "new.go", "package keep\n")
+	if err := os.Remove(filepath.Join(dir, "gone.go")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	gitAddCommit(t, dir, "change")
+	writeFile(t, dir, "list.txt", "docs/a.md\n\n./new.go\r\n")
+
+	cfg := &Config{Files: []string{"x.go,keep.go"}, FilesFrom: "list.txt", ChangedSince: "base"}
+	cfg.logger = newEventLogger(io.Discard, false)
+	got, err := cfg.targetFiles(context.Background())
+	if err != nil {
+		t.Fatalf("targetFiles: %v", err)
+	}
+	want := []string{"docs/a.md", "keep.go", "new.go", "x.go"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("targets = %v, want %v", got, want)
+	}
+
+	nul, err := readFileList(strings.NewReader("a b.go\x00c.go\x00 lead.go\x00trail.go \x00"))
+	if err != nil || !slices.Equal(nul, []string{"a b.go", "c.go", " lead.go", "trail.go "}) {
+		t.Fatalf("NUL-separated list = %v (%v)", nul, err)
+	}
+	lines, err := readFileList(strings.NewReader("x.go\r\n   \nspaced.go \n"))
+	if err != nil || !slices.Equal(lines, []string{"x.go", "spaced.go "}) {
+		t.Fatalf("newline-separated list = %v (%v)", lines, err)
+	}
+	if _, err := (&Config{FilesFrom: "missing.txt"}).targetFiles(context.Background()); err == nil {
+		t.Fatalf("expected a missing --files-from file to fail")
+	}
+	typo := &Config{ChangedSince: "bsae"}
+	typo.logger = newEventLogger(io.Discard, false)
+	if _, err := typo.targetFiles(context.Background()); err == nil || !strings.Contains(err.Error(), "changed-since bsae: unknown revision") {
+		t.Fatalf("expected an unknown --changed-since ref to fail, got %v", err)
+	}
+}
+
+func TestRunTrunkCheckTargetsBatches(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell stubs not supported on Windows in this test")
+	}
+	dir := t.TempDir()
+	prev := mustChdir(t, dir)
+	t.Cleanup(func() { mustChdir(t, prev) })
+	stubPath := filepath.Join(dir, trunkExecutableName())
+	script := `#!/bin/sh
+echo "$@" >> calls.txt
+shift 2
+sep=""
+printf '{"issues": ['
+for f in "$@"; do
+  printf '%s{"file": "%s", "linter": "stub", "message": "m"}' "$sep" "$f"
+  sep=","
+done
+printf ']}\n'
+exit 1
+`
+	if err := os.WriteFile(stubPath, []byte(script), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	prevLimit := maxTargetArgBytes
+	maxTargetArgBytes = 12
+	t.Cleanup(func() { maxTargetArgBytes = prevLimit })
+
+	cfg := &Config{TrunkPath: stubPath, Autofix: "none", Files: []string{"a.go,b.go,c.go"}, LintFailOn: "error"}
+	cfg.logger = newEventLogger(io.Discard, false)
+	exitErr = nil
+	t.Cleanup(func() { exitErr = nil })
+	if err := runTrunkCheck(context.Background(), cfg); err != nil {
+		t.Fatalf("runTrunkCheck: %v", err)
+	}
+	calls, err := os.ReadFile("calls.txt")
+	if err != nil {
+		t.Fatalf("read calls: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 2 || lines[0] != "check --output=json a.go b.go" || lines[1] != "check --output=json c.go" {
+		t.Fatalf("unexpected trunk invocations %q", lines)
+	}
+	if cfg.lintReport == nil || len(cfg.lintReport.Issues) != 3 {
+		t.Fatalf("expected issues from every batch, got %+v", cfg.lintReport)
+	}
+	if exitErr != nil {
+		t.Fatalf("expected warnings not to gate with --lint-fail-on=error, got %v", exitErr)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hotspot severity tiers.
+//
+// By default every hotspot is a SARIF `note`. --hotspot-tiers maps the top of
+// the ranking onto `error` and `warning` so code scanning alerts can be
+// triaged by severity. Each entry is level=threshold, where the threshold is
+// a percentile (p99), a rank cutoff (top10) or a minimum score (7.5). The
+// ruleId stays `hotspot` for every tier; the tier is recorded in the result's
+// `level` and `properties.tier`.
+
+const (
+	tierKindPercentile = "percentile"
+	tierKindTop        = "top"
+	tierKindScore      = "score"
+)
+
+var tierLevelOrder = []string{"error", "warning", "note"}
+
+type severityTier struct {
+	Level string
+	Kind  string
+	Value float64
+	Spec  string
+}
+
+type hotspotTiers []severityTier
+
+// parseHotspotTiers parses "error=p99,warning=top25" style specs. Tiers are
+// returned most severe first so the first match wins.
+func parseHotspotTiers(raw string) (hotspotTiers, error) {
+	byLevel := map[string]severityTier{}
+	for _, entry := range splitCSV(raw) {
+		level, spec, ok := strings.Cut(entry, "=")
+		level = strings.ToLower(strings.TrimSpace(level))
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		if !ok || spec == "" {
+			return nil, fmt.Errorf("invalid hotspot-tiers entry %q: expected level=threshold (e.g. error=p99)", entry)
+		}
+		if !validTierLevel(level) {
+			return nil, fmt.Errorf("invalid hotspot-tiers level %q (expected error, warning or note)", level)
+		}
+		if _, dup := byLevel[level]; dup {
+			return nil, fmt.Errorf("hotspot-tiers level %q set more than once", level)
+		}
+		tier := severityTier{Level: level, Spec: spec}
+		var err error
+		switch {
+		case strings.HasPrefix(spec, "p"):
+			tier.Kind = tierKindPercentile
+			tier.Value, err = strconv.ParseFloat(spec[1:], 64)
+			if err == nil && (tier.Value <= 0 || tier.Value >= 100) {
+				err = fmt.Errorf("percentile must be between 0 and 100")
+			}
+		case strings.HasPrefix(spec, "top"):
+			tier.Kind = tierKindTop
+			var n int
+			n, err = strconv.Atoi(spec[3:])
+			if err == nil && n <= 0 {
+				err = fmt.Errorf("rank cutoff must be positive")
+			}
+			tier.Value = float64(n)
+		default:
+			tier.Kind = tierKindScore
+			tier.Value, err = strconv.ParseFloat(spec, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid hotspot-tiers threshold %q for %s: %v", spec, level, err)
+		}
+		byLevel[level] = tier
+	}
+	var tiers hotspotTiers
+	for _, level := range tierLevelOrder {
+		if t, ok := byLevel[level]; ok {
+			tiers = append(tiers, t)
+		}
+	}
+	return tiers, nil
+}
+
+func validTierLevel(level string) bool {
+	for _, l := range tierLevelOrder {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+func (t severityTier) matches(h Hotspot) bool {
+	switch t.Kind {
+	case tierKindPercentile:
+		return h.Rank > 0 && h.Percentile > t.Value
+	case tierKindTop:
+		return h.Rank > 0 && float64(h.Rank) <= t.Value
+	default:
+		return h.Score >= t.Value
+	}
+}
+
+// level returns the SARIF level for h; hotspots outside every tier stay notes.
+func (tiers hotspotTiers) level(h Hotspot) string {
+	for _, t := range tiers {
+		if t.matches(h) {
+			return t.Level
+		}
+	}
+	return "note"
+}
+
+func (tiers hotspotTiers) describe() string {
+	if len(tiers) == 0 {
+		return "uniform note severity"
+	}
+	parts := make([]string, 0, len(tiers))
+	for _, t := range tiers {
+		parts = append(parts, fmt.Sprintf("%s=%s", t.Level, t.Spec))
+	}
+	return "severity tiers " + strings.Join(parts, ",")
+}
+
+func (tiers hotspotTiers) properties() map[string]string {
+	props := map[string]string{}
+	for _, t := range tiers {
+		props[t.Level] = t.Spec
+	}
+	return props
+}
+
+// hotspotPercentile is the share of ranked files at or below rank, so rank 1
+// of 200 sits at the 100th percentile and rank 200 at the 0.5th. A pN tier
+// matches strictly above N, keeping exactly the top (100-N)% of files.
+func hotspotPercentile(rank, total int) float64 {
+	if rank <= 0 || total <= 0 {
+		return 0
+	}
+	return 100 * float64(total-rank+1) / float64(total)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseHotspotTiers(t *testing.T) {
+	tiers, err := parseHotspotTiers("warning=p90, error=top3,note=1.5")
+	if err != nil {
+		t.Fatalf("parseHotspotTiers: %v", err)
+	}
+	if len(tiers) != 3 || tiers[0].Level != "error" || tiers[1].Level != "warning" || tiers[2].Level != "note" {
+		t.Fatalf("expected tiers ordered by severity, got %+v", tiers)
+	}
+	if tiers[0].Kind != tierKindTop || tiers[0].Value != 3 || tiers[1].Kind != tierKindPercentile || tiers[2].Kind != tierKindScore {
+		t.Fatalf("unexpected tier kinds: %+v", tiers)
+	}
+	if empty, err := parseHotspotTiers(""); err != nil || len(empty) != 0 {
+		t.Fatalf("expected empty spec to disable tiers, got %+v, %v", empty, err)
+	}
+	for _, bad := range []string{"critical=p99", "error", "error=p100", "error=top0", "error=high", "error=p99,error=top1"} {
+		if _, err := parseHotspotTiers(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHotspotTiersLevel(t *testing.T) {
+	tiers, err := parseHotspotTiers("error=top1,warning=p50,note=1")
+	if err != nil {
+		t.Fatalf("parseHotspotTiers: %v", err)
+	}
+	total := 4
+	var hs []Hotspot
+	for i, score := range []float64{9, 6, 3, 0.5} {
+		hs = append(hs, Hotspot{File: "f", Score: score, Rank: i + 1, Percentile: hotspotPercentile(i+1, total)})
+	}
+	want := []string{"error", "warning", "note", "note"}
+	for i, h := range hs {
+		if got := tiers.level(h); got != want[i] {
+			t.Errorf("rank %d (percentile %.1f): got %s, want %s", h.Rank, h.Percentile, got, want[i])
+		}
+	}
+}
+
+func TestHotspotTiersSingleFile(t *testing.T) {
+	tiers, err := parseHotspotTiers("error=p99,warning=p90")
+	if err != nil {
+		t.Fatalf("parseHotspotTiers: %v", err)
+	}
+	h := Hotspot{File: "main.go", Score: 1, Rank: 1, Percentile: hotspotPercentile(1, 1)}
+	if h.Percentile != 100 {
+		t.Fatalf("percentile = %.1f, want 100", h.Percentile)
+	}
+	if got := tiers.level(h); got != "error" {
+		t.Errorf("level = %s, want error", got)
+	}
+}
+
+func TestHotspotPercentileTopShare(t *testing.T) {
+	tiers, err := parseHotspotTiers("warning=p90")
+	if err != nil {
+		t.Fatalf("parseHotspotTiers: %v", err)
+	}
+	total, warned := 200, 0
+	for rank := 1; rank <= total; rank++ {
+		h := Hotspot{File: "f", Score: 1, Rank: rank, Percentile: hotspotPercentile(rank, total)}
+		if tiers.level(h) == "warning" {
+			warned++
+		}
+	}
+	if warned != 20 {
+		t.Errorf("p90 of %d files warned %d, want 20", total, warned)
+	}
+}
+
+func TestWriteHotspotSARIFSeverityTiers(t *testing.T) {
+	tiers, err := parseHotspotTiers("error=top1,warning=top2")
+	if err != nil {
+		t.Fatalf("parseHotspotTiers: %v", err)
+	}
+	report := &hotspotReport{
+		Hotspots: []Hotspot{{File: "a.go", Score: 3}, {File: "b.go", Score: 2}, {File: "c.go", Score: 1}},
+		Tiers:    tiers,
+	}
+	path := filepath.Join(t.TempDir(), "out.sarif")
+	if err := writeHotspotSARIF(path, report); err != nil {
+		t.Fatalf("writeHotspotSARIF: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read SARIF: %v", err)
+	}
+	var log SarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal SARIF: %v", err)
+	}
+	want := []string{"error", "warning", "note"}
+	for i, r := range log.Runs[0].Results {
+		if r.RuleID != "hotspot" || r.Level != want[i] || r.Properties["tier"] != want[i] {
+			t.Errorf("result %d: ruleId=%s level=%s tier=%v, want hotspot/%s", i, r.RuleID, r.Level, r.Properties["tier"], want[i])
+		}
+	}
+}